@@ -4,7 +4,10 @@
 package kratos
 
 import (
+	"context"
+	"net"
 	"sync"
+	"time"
 
 	"github.com/xmidt-org/wrp-go/v3"
 	"go.uber.org/zap"
@@ -13,8 +16,13 @@ import (
 // Client is what function calls we expose to the user of kratos
 type Client interface {
 	Hostname() string
+	ConnectionURL() string
+	RemoteAddr() net.Addr
+	RedirectChain() []string
 	HandlerRegistry() HandlerRegistry
 	Send(message *wrp.Message)
+	SendContext(ctx context.Context, message *wrp.Message) error
+	Request(ctx context.Context, message *wrp.Message) (*wrp.Message, error)
 	Close() error
 }
 
@@ -23,40 +31,101 @@ type sendWRPFunc func(*wrp.Message)
 
 type client struct {
 	deviceID        string
-	userAgent       string
-	deviceProtocols string
-	hostname        string
+	connectionInfo  connectionInfo
+	hostnameLock    sync.RWMutex
+	hostnameFunc    func(string) string
+	destinationURL  string
 	registry        HandlerRegistry
 	handlePingMiss  HandlePingMiss
 	encoderSender   encoderSender
+	outboundSender  outboundSender
 	decoderSender   decoderSender
-	connection      websocketConnection
+	connection      *managedConnection
 	headerInfo      *clientHeader
 	logger          *zap.Logger
 	done            chan struct{}
 	wg              sync.WaitGroup
 	pingConfig      PingConfig
+	pinged          chan string
+	reconnectConfig ReconnectConfig
+	redirectPolicy  RedirectPolicy
+	transactions    *transactions
+	listeners       listeners
+	metrics         *Metrics
+	dialer          Dialer
+	tokenAcquirer   TokenAcquirer
 	once            sync.Once
 }
 
-// used to track everything that we want to know about the client headers
-type clientHeader struct {
-	deviceName   string
-	firmwareName string
-	modelName    string
-	manufacturer string
-}
-
 // websocketConnection maintains the websocket connection upstream (to XMiDT).
 type websocketConnection interface {
 	WriteMessage(messageType int, data []byte) error
+	SetWriteDeadline(t time.Time) error
+	SetReadDeadline(t time.Time) error
 	ReadMessage() (messageType int, p []byte, err error)
 	Close() error
 }
 
-// Hostname provides the client's hostname.
+// connectionInfo describes the connection the client last made.
+type connectionInfo struct {
+	url        string
+	hostname   string
+	remoteAddr net.Addr
+	redirected []string
+}
+
+// Hostname provides the client's hostname, which is the host of the URL
+// the client is connected to unless ClientConfig.HostnameFunc says
+// otherwise.
 func (c *client) Hostname() string {
-	return c.hostname
+	c.hostnameLock.RLock()
+	defer c.hostnameLock.RUnlock()
+	return c.connectionInfo.hostname
+}
+
+// ConnectionURL provides the websocket URL the client is connected to, after
+// following any redirects.
+func (c *client) ConnectionURL() string {
+	c.hostnameLock.RLock()
+	defer c.hostnameLock.RUnlock()
+	return c.connectionInfo.url
+}
+
+// RemoteAddr provides the address of the other end of the connection.  When
+// connecting through a proxy, this is the proxy's address.
+func (c *client) RemoteAddr() net.Addr {
+	c.hostnameLock.RLock()
+	defer c.hostnameLock.RUnlock()
+	return c.connectionInfo.remoteAddr
+}
+
+// RedirectChain provides the URLs that redirected the client to its
+// ConnectionURL, in the order they were dialed.  It is empty when the last
+// connection wasn't redirected.
+func (c *client) RedirectChain() []string {
+	c.hostnameLock.RLock()
+	defer c.hostnameLock.RUnlock()
+	return append([]string{}, c.connectionInfo.redirected...)
+}
+
+// connectionDetails describes the client's connection, for handlers.
+func (c *client) connectionDetails() Connection {
+	c.hostnameLock.RLock()
+	defer c.hostnameLock.RUnlock()
+	return Connection{
+		DeviceID:      c.deviceID,
+		URL:           c.connectionInfo.url,
+		Hostname:      c.connectionInfo.hostname,
+		RemoteAddr:    c.connectionInfo.remoteAddr,
+		RedirectChain: append([]string{}, c.connectionInfo.redirected...),
+	}
+}
+
+// setConnectionInfo records the connection the client has made.
+func (c *client) setConnectionInfo(info connectionInfo) {
+	c.hostnameLock.Lock()
+	c.connectionInfo = info
+	c.hostnameLock.Unlock()
 }
 
 // HandlerRegistry returns the HandlerRegistry that the client maintains.
@@ -69,17 +138,32 @@ func (c *client) Send(message *wrp.Message) {
 	c.encoderSender.EncodeAndSend(message)
 }
 
-// Close closes connections downstream and the socket upstream.
+// SendContext queues the message for writing to XMiDT, waiting until it has
+// been encoded and handed off to be written.  An error is returned if the
+// client is closed, a queue rejects the message, the message cannot be
+// encoded, or the context is done first.
+func (c *client) SendContext(ctx context.Context, message *wrp.Message) error {
+	return c.encoderSender.EncodeAndSendContext(ctx, message)
+}
+
+// Close closes connections downstream and the socket upstream.  Messages
+// already read are handled, and their responses written, before the socket is
+// closed.
 func (c *client) Close() error {
 	var connectionErr error
 	c.once.Do(func() {
 		c.logger.Info("Closing client...")
 		close(c.done)
+		// expiring the read deadline unblocks the read loop so it can exit,
+		// while leaving the socket open for the responses still to come.
+		_ = c.connection.SetReadDeadline(time.Now())
 		c.wg.Wait()
 		c.decoderSender.Close()
 		c.encoderSender.Close()
 		connectionErr = c.connection.Close()
-		c.connection = nil
+		if url := c.ConnectionURL(); url != "" {
+			c.listeners.onDisconnect(newConnectionEvent(url, 0, ErrClientClosed))
+		}
 		// TODO: if this fails, can we really do anything. Is there potential for leaks?
 		// if err != nil {
 		// 	return emperror.Wrap(err, "Failed to close connection")
@@ -104,8 +188,23 @@ func (c *client) read() {
 
 			_, serverMessage, err := c.connection.ReadMessage()
 			if err != nil {
-				c.logger.Error("Failed to read message. Exiting out of read loop.", zap.Error(err))
-				return
+				select {
+				case <-c.done:
+					c.logger.Info("Stopped reading from socket.")
+					return
+				default:
+				}
+				c.listeners.onDisconnect(newConnectionEvent(c.ConnectionURL(), 0, err))
+				if !c.reconnectConfig.Enabled {
+					c.logger.Error("Failed to read message. Exiting out of read loop.", zap.Error(err))
+					return
+				}
+				c.logger.Error("Failed to read message. Reconnecting.", zap.Error(err))
+				if err = c.reconnect(); err != nil {
+					c.logger.Error("Failed to reconnect. Exiting out of read loop.", zap.Error(err))
+					return
+				}
+				continue
 			}
 			c.decoderSender.DecodeAndSend(serverMessage)
 
@@ -4,20 +4,31 @@
 package kratos
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
-	"strings"
+	"net/url"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/xmidt-org/sallust"
 	"github.com/xmidt-org/wrp-go/v3"
 	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
 )
 
 const (
-	// Time allowed to write a message to the peer.
+	// Default time allowed to write a message to the peer.
 	writeWait = time.Duration(10) * time.Second
+
+	// DefaultPingWait is how long the client waits for a ping when
+	// PingConfig.PingWait is not set.
+	DefaultPingWait = time.Minute
+
+	// DefaultMaxPingMiss is the number of missed pings tolerated when
+	// PingConfig.MaxPingMiss is not set.
+	DefaultMaxPingMiss = 1
 )
 
 var (
@@ -30,6 +41,13 @@ type ClientConfig struct {
 	FirmwareName         string
 	ModelName            string
 	Manufacturer         string
+	BootTime             time.Time
+	LastRebootReason     string
+	InterfaceUsed        string
+	Protocol             string
+	UserAgent            string
+	Convey               map[string]any
+	Headers              http.Header
 	DestinationURL       string
 	OutboundQueue        QueueConfig
 	WRPEncoderQueue      QueueConfig
@@ -37,52 +55,107 @@ type ClientConfig struct {
 	HandlerRegistryQueue QueueConfig
 	HandleMsgQueue       QueueConfig
 	Handlers             []HandlerConfig
+	HandlerOrder         HandlerOrder
+	Middleware           []Middleware
 	HandlePingMiss       HandlePingMiss
 	ClientLogger         *zap.Logger
 	PingConfig           PingConfig
+	WriteConfig          WriteConfig
+	Reconnect            ReconnectConfig
+	TLS                  *TLSConfig
+	Redirect             RedirectPolicy
+	HostnameFunc         func(connectionURL string) string
+	Dialer               Dialer
+	DialerConfig         DialerConfig
+	TokenAcquirer        TokenAcquirer
+	Listeners            []ClientListener
+	Metrics              *Metrics
 }
 
 // QueueConfig is used to configure all the queues used to make kratos asynchronous.
+// MaxWorkers is not used by the OutboundQueue, because the websocket only
+// supports one concurrent writer.  Timeout is only used by the
+// HandleMsgQueue, and is how long a handler is given to handle a message
+// unless its HandlerConfig sets its own.  Zero means no limit.
 type QueueConfig struct {
-	MaxWorkers int
-	Size       int
+	MaxWorkers int            `yaml:"maxWorkers"`
+	Size       int            `yaml:"size"`
+	Overflow   OverflowPolicy `yaml:"overflow"`
+	Timeout    time.Duration  `yaml:"timeout"`
+
+	// workers is a worker pool shared with other queues.  When it is set,
+	// MaxWorkers is ignored.
+	workers *semaphore.Weighted
+
+	// codecs is a pool of encoders and decoders shared with other queues.
+	// It is only used by the WRPEncoderQueue and WRPDecoderQueue.
+	codecs *codecs
+}
+
+// newWorkers returns the shared worker pool if there is one, or creates a new
+// pool of MaxWorkers workers.
+func (q QueueConfig) newWorkers() *semaphore.Weighted {
+	if q.workers != nil {
+		return q.workers
+	}
+	numWorkers := q.MaxWorkers
+	if numWorkers < minWorkers {
+		numWorkers = minWorkers
+	}
+	return semaphore.NewWeighted(int64(numWorkers))
+}
+
+// newCodecs returns the shared encoders and decoders if there are any, or
+// creates a new pool of them.
+func (q QueueConfig) newCodecs() *codecs {
+	if q.codecs != nil {
+		return q.codecs
+	}
+	return newCodecs()
 }
 
 type PingConfig struct {
-	PingWait    time.Duration
-	MaxPingMiss int
+	PingWait    time.Duration `yaml:"pingWait"`
+	MaxPingMiss int           `yaml:"maxPingMiss"`
 }
 
-// NewClient is used to create a new kratos Client from a ClientConfig.
+// NewClient is used to create a new kratos Client from a ClientConfig.  A
+// PingWait of zero becomes DefaultPingWait, and a MaxPingMiss below one
+// becomes DefaultMaxPingMiss.  BootTime defaults to when the client is
+// created, Protocol to DefaultProtocol, and UserAgent to one made from the
+// firmware, model and manufacturer.  New is an alternative that checks its options
+// before connecting.
 func NewClient(config ClientConfig) (Client, error) {
+	c, err := newClient(config)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.connect(context.Background()); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// newClient creates a client and starts its queues, but doesn't connect it.
+func newClient(config ClientConfig) (*client, error) {
 	if config.HandlePingMiss == nil {
 		return nil, errNilHandlePingMiss
 	}
-
-	inHeader := &clientHeader{
-		deviceName:   config.DeviceName,
-		firmwareName: config.FirmwareName,
-		modelName:    config.ModelName,
-		manufacturer: config.Manufacturer,
+	if err := config.HandlerOrder.validate(); err != nil {
+		return nil, err
+	}
+	for _, q := range []QueueConfig{config.OutboundQueue, config.WRPEncoderQueue, config.WRPDecoderQueue, config.HandlerRegistryQueue, config.HandleMsgQueue} {
+		if err := q.Overflow.validate(); err != nil {
+			return nil, err
+		}
 	}
 
-	newConnection, connectionURL, err := createConnection(inHeader, config.DestinationURL)
-
+	inHeader, err := newClientHeader(config)
 	if err != nil {
 		return nil, err
 	}
 
-	pinged := make(chan string)
-	newConnection.SetPingHandler(func(appData string) error {
-		pinged <- appData
-		err := newConnection.WriteControl(websocket.PongMessage, []byte(appData), time.Now().Add(writeWait))
-		return err
-	})
-
-	// at this point we know that the URL connection is legitimate, so we can do some string manipulation
-	// with the knowledge that `:` will be found in the string twice
-	connectionURL = connectionURL[len("ws://"):strings.LastIndex(connectionURL, ":")]
-
 	var logger *zap.Logger
 	if config.ClientLogger != nil {
 		logger = config.ClientLogger
@@ -90,75 +163,196 @@ func NewClient(config ClientConfig) (Client, error) {
 		logger = sallust.Default()
 	}
 	if config.PingConfig.MaxPingMiss <= 0 {
-		config.PingConfig.MaxPingMiss = 1
+		config.PingConfig.MaxPingMiss = DefaultMaxPingMiss
 	}
 	if config.PingConfig.PingWait == 0 {
-		config.PingConfig.PingWait = time.Minute
+		config.PingConfig.PingWait = DefaultPingWait
+	}
+
+	tlsConfig, err := config.TLS.NewTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config.HostnameFunc == nil {
+		config.HostnameFunc = hostnameFromURL
 	}
 
-	sender := NewSender(newConnection, config.OutboundQueue.MaxWorkers, config.OutboundQueue.Size, logger)
-	encoder := NewEncoderSender(sender, config.WRPEncoderQueue.MaxWorkers, config.WRPEncoderQueue.Size, logger)
+	dialer, err := newDialer(config.Dialer, config.DialerConfig, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
 
 	newClient := &client{
 		deviceID:        inHeader.deviceName,
-		userAgent:       "WebPA-1.6(" + inHeader.firmwareName + ";" + inHeader.modelName + "/" + inHeader.manufacturer + ";)",
-		deviceProtocols: "TODO-what-to-put-here",
-		hostname:        connectionURL,
+		destinationURL:  config.DestinationURL,
 		handlePingMiss:  config.HandlePingMiss,
-		encoderSender:   encoder,
-		connection:      newConnection,
 		headerInfo:      inHeader,
 		done:            make(chan struct{}, 1),
 		logger:          logger,
 		pingConfig:      config.PingConfig,
+		pinged:          make(chan string, 1),
+		reconnectConfig: config.Reconnect,
+		redirectPolicy:  config.Redirect,
+		hostnameFunc:    config.HostnameFunc,
+		dialer:          dialer,
+		tokenAcquirer:   config.TokenAcquirer,
+		transactions:    newTransactions(),
+		listeners:       config.Listeners,
+		metrics:         config.Metrics,
 	}
 
-	newClient.registry, err = NewHandlerRegistry(config.Handlers)
+	newClient.connection = newManagedConnection(nil)
+	newClient.outboundSender = NewSender(newClient.connection, config.OutboundQueue, config.WriteConfig, config.Metrics, logger)
+	newClient.encoderSender = NewEncoderSender(newClient.outboundSender, config.WRPEncoderQueue, config.Metrics, logger)
+
+	newClient.registry, err = NewOrderedHandlerRegistry(config.HandlerOrder, config.Handlers)
 	if err != nil {
 		logger.Warn("failed to initialize all handlers for registry", zap.Error(err))
 	}
+	newClient.registry.Use(config.Middleware...)
 
-	downstreamSender := NewDownstreamSender(newClient.Send, config.HandleMsgQueue.MaxWorkers, config.HandleMsgQueue.Size, logger)
-	registryHandler := NewRegistryHandler(newClient.Send, newClient.registry, downstreamSender, config.HandlerRegistryQueue.MaxWorkers, config.HandlerRegistryQueue.Size, newClient.deviceID, logger)
-	decoder := NewDecoderSender(registryHandler, config.WRPDecoderQueue.MaxWorkers, config.WRPDecoderQueue.Size, logger)
+	downstreamSender := NewDownstreamSender(newClient.Send, config.HandleMsgQueue, config.Metrics, logger)
+	downstreamSender.connection = newClient.connectionDetails
+	registryHandler := NewRegistryHandler(newClient.Send, newClient.registry, downstreamSender, config.HandlerRegistryQueue, newClient.deviceID, config.Metrics, logger)
+	interceptor := &responseInterceptor{transactions: newClient.transactions, next: registryHandler}
+	decoder := NewDecoderSender(interceptor, config.WRPDecoderQueue, config.Metrics, logger)
 	newClient.decoderSender = decoder
 
-	pingTimer := time.NewTimer(newClient.pingConfig.PingWait)
+	return newClient, nil
+}
+
+// connect makes the client's initial connection to XMiDT, giving up when the
+// context is done, and then starts reading messages and watching for pings.
+func (c *client) connect(ctx context.Context) error {
+	newConnection, info, err := c.dial(ctx, 0)
+	if err != nil {
+		return err
+	}
+	if err = c.connection.set(newConnection); err != nil {
+		return err
+	}
+	c.setConnectionInfo(info)
+	c.listeners.onConnect(newConnectionEvent(info.url, 0, nil))
+
+	pingTimer := time.NewTimer(c.pingConfig.PingWait)
 
-	newClient.wg.Add(2)
-	go newClient.checkPing(pingTimer, pinged)
+	c.wg.Add(2)
+	go c.checkPing(pingTimer, c.pinged)
 
-	go newClient.read()
+	go c.read()
 
-	return newClient, nil
+	return nil
+}
+
+// dial creates a new websocket connection to XMiDT and sets it up to report
+// pings to the client.  The attempt is the reconnect attempt, or zero for the
+// initial connection.  Dialing gives up when the context is done.
+func (c *client) dial(ctx context.Context, attempt int) (*websocket.Conn, connectionInfo, error) {
+	headers := make(http.Header)
+	if c.tokenAcquirer != nil {
+		authorization, err := authorizationHeader(ctx, c.tokenAcquirer)
+		if err != nil {
+			return nil, connectionInfo{}, fmt.Errorf("failed to acquire token: %w", err)
+		}
+		headers.Set("Authorization", authorization)
+	}
+
+	onRedirect := func(from, location string, statusCode int) {
+		e := newConnectionEvent(from, attempt, nil)
+		e.Location = location
+		e.StatusCode = statusCode
+		c.listeners.onRedirect(e)
+	}
+
+	newConnection, connectionURL, redirected, err := createConnection(ctx, c.dialer, c.headerInfo, c.destinationURL, headers, c.redirectPolicy, onRedirect)
+	if err != nil {
+		if c.tokenAcquirer != nil {
+			invalidateToken(c.tokenAcquirer, err)
+		}
+		return nil, connectionInfo{}, err
+	}
+	info := connectionInfo{
+		url:        connectionURL,
+		hostname:   c.hostnameFunc(connectionURL),
+		remoteAddr: newConnection.RemoteAddr(),
+		redirected: redirected,
+	}
+
+	newConnection.SetPingHandler(func(appData string) error {
+		c.pingReceived(appData)
+		// the pong is written by the sender, which owns all writes.
+		c.outboundSender.SendControl(websocket.PongMessage, []byte(appData))
+		return nil
+	})
+	return newConnection, info, nil
 }
 
-// private func used to generate the client that we're looking to produce
-func createConnection(headerInfo *clientHeader, httpURL string) (connection *websocket.Conn, wsURL string, err error) {
+// hostnameFromURL pulls the hostname out of the websocket URL connected to,
+// without the port or the brackets around an IPv6 address.
+func hostnameFromURL(connectionURL string) string {
+	u, err := url.Parse(connectionURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// websocketURL converts an http or https URL to the matching ws or wss URL.
+// URLs that are already websocket URLs are left alone.
+func websocketURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	case "ws", "wss":
+	default:
+		return "", fmt.Errorf("unsupported URL scheme [%v]", u.Scheme)
+	}
+	return u.String(), nil
+}
+
+// createConnection dials XMiDT, following redirects.  It returns the
+// websocket URL connected to, and the URLs that redirected to it.  Dialing
+// gives up when the context is done.
+func createConnection(ctx context.Context, dialer Dialer, headerInfo *clientHeader, httpURL string, headers http.Header, policy RedirectPolicy, onRedirect func(from, location string, statusCode int)) (connection *websocket.Conn, wsURL string, redirected []string, err error) {
 	_, err = wrp.ParseDeviceID(headerInfo.deviceName)
 
 	if err != nil {
-		return nil, "", err
+		return nil, "", nil, err
 	}
 
 	// make a header and put some data in that (including MAC address)
-	// TODO: find special function for user agent
-	headers := make(http.Header)
-	headers.Add("X-Webpa-Device-Name", headerInfo.deviceName)
-	headers.Add("X-Webpa-Firmware-Name", headerInfo.firmwareName)
-	headers.Add("X-Webpa-Model-Name", headerInfo.modelName)
-	headers.Add("X-Webpa-Manufacturer", headerInfo.manufacturer)
+	headerInfo.apply(headers)
 
-	// make sure destUrl's protocol is websocket (ws)
-	wsURL = strings.Replace(httpURL, "http", "ws", 1)
+	// make sure destUrl's protocol is websocket (ws or wss)
+	wsURL, err = websocketURL(httpURL)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	first, err := url.Parse(wsURL)
+	if err != nil {
+		return nil, "", nil, err
+	}
 
-	// creates a new client connection given the URL string
-	connection, resp, err := websocket.DefaultDialer.Dial(wsURL, headers)
-	for errors.Is(err, websocket.ErrBadHandshake) && resp != nil && resp.StatusCode == http.StatusTemporaryRedirect {
-		// Get url to which we are redirected and reconfigure it
-		wsURL = strings.Replace(resp.Header.Get("Location"), "http", "ws", 1)
+	// creates a new client connection given the URL string, following the
+	// redirects the policy allows.
+	redirects := newRedirects(policy, first)
+	connection, resp, err := dialContext(ctx, dialer, wsURL, headers)
+	for errors.Is(err, websocket.ErrBadHandshake) && resp != nil && policy.follows(resp.StatusCode) {
+		resp.Body.Close()
+		location, nextURL, redirectErr := redirects.next(resp.Header.Get("Location"), resp.StatusCode)
+		if redirectErr != nil {
+			return nil, "", nil, redirectErr
+		}
+		onRedirect(wsURL, location.String(), resp.StatusCode)
+		wsURL = nextURL
 
-		connection, resp, err = websocket.DefaultDialer.Dial(wsURL, headers)
+		connection, resp, err = dialContext(ctx, dialer, wsURL, redirects.headers(headers))
 	}
 	if resp != nil {
 		defer resp.Body.Close()
@@ -168,8 +362,41 @@ func createConnection(headerInfo *clientHeader, httpURL string) (connection *web
 		if resp != nil {
 			err = createHTTPError(resp, err)
 		}
-		return nil, "", err
+		return nil, "", nil, err
 	}
 
-	return connection, wsURL, nil
+	return connection, wsURL, redirects.redirected(), nil
+}
+
+// dialContext dials with the Dialer, returning as soon as the context is done.
+// Dialers may only use the context's deadline for the websocket handshake, so
+// a dial that is given up on is left to finish in the background, and the
+// connection it makes is closed.
+func dialContext(ctx context.Context, dialer Dialer, wsURL string, headers http.Header) (*websocket.Conn, *http.Response, error) {
+	type result struct {
+		connection *websocket.Conn
+		resp       *http.Response
+		err        error
+	}
+	results := make(chan result, 1)
+	go func() {
+		connection, resp, err := dialer.DialContext(ctx, wsURL, headers)
+		results <- result{connection: connection, resp: resp, err: err}
+	}()
+
+	select {
+	case r := <-results:
+		return r.connection, r.resp, r.err
+	case <-ctx.Done():
+		go func() {
+			r := <-results
+			if r.connection != nil {
+				r.connection.Close()
+			}
+			if r.resp != nil {
+				r.resp.Body.Close()
+			}
+		}()
+		return nil, nil, ctx.Err()
+	}
 }
@@ -44,6 +44,7 @@ func (c *client) checkPing(inTimer *time.Timer, pinged <-chan string) {
 		// if we hit the timer, we've missed a ping.
 		case <-inTimer.C:
 			c.logger.Error("Ping miss, calling handler", zap.Int("count", count))
+			c.metrics.pingMiss()
 			err := c.handlePingMiss()
 			if err != nil {
 				c.logger.Error("Error handling ping miss:", zap.Error(err))
@@ -57,3 +58,13 @@ func (c *client) checkPing(inTimer *time.Timer, pinged <-chan string) {
 		}
 	}
 }
+
+// pingReceived tells checkPing about a ping.  One ping is kept for checkPing
+// while it is busy, such as calling HandlePingMiss, and any more are dropped
+// so that reading never blocks, even if nobody is watching for pings anymore.
+func (c *client) pingReceived(appData string) {
+	select {
+	case c.pinged <- appData:
+	default:
+	}
+}
@@ -76,3 +76,15 @@ func TestPing(t *testing.T) {
 
 	assert.Equal(t, pingMissCount, 1)
 }
+
+func TestPingReceived(t *testing.T) {
+	assert := assert.New(t)
+
+	newClient := &client{pinged: make(chan string, 1)}
+
+	// a ping arriving while nobody is receiving is kept, and more don't block.
+	newClient.pingReceived("first")
+	newClient.pingReceived("second")
+	assert.Equal("first", <-newClient.pinged)
+	assert.Empty(newClient.pinged)
+}
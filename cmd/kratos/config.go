@@ -0,0 +1,227 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+var (
+	errNoDevice      = errors.New("a device name is required")
+	errNoURL         = errors.New("a destination url is required")
+	errInvalidRule   = errors.New("handler rules must be of the form regexp=action")
+	errUnknownAction = errors.New("unknown handler action")
+	errREPLStdin     = errors.New("the repl and sending from stdin both read stdin")
+	errInvalidURL    = errors.New("the destination url must be an http, https, ws or wss url")
+)
+
+// config is everything needed to emulate a device.  It can be loaded from a
+// JSON file, and flags override anything in the file.  Repeated flags add to
+// the lists in the file.
+type config struct {
+	File         string   `json:"-"`
+	Device       string   `json:"device"`
+	Firmware     string   `json:"firmware"`
+	Model        string   `json:"model"`
+	Manufacturer string   `json:"manufacturer"`
+	URL          string   `json:"url"`
+	Token        string   `json:"token"`
+	CAFiles      []string `json:"caFiles"`
+	CertFile     string   `json:"certFile"`
+	KeyFile      string   `json:"keyFile"`
+	Insecure     bool     `json:"insecure"`
+	Reconnect    bool     `json:"reconnect"`
+	Handlers     []rule   `json:"handlers"`
+	Send         []string `json:"send"`
+	Stdin        bool     `json:"stdin"`
+	Once         bool     `json:"once"`
+	Timeout      duration `json:"timeout"`
+	Verbose      bool     `json:"verbose"`
+	REPL         bool     `json:"repl"`
+	History      string   `json:"history"`
+}
+
+// defaultConfig is the config before any file or flags are applied.
+func defaultConfig() config {
+	return config{
+		Firmware:     "kratos",
+		Model:        "kratos",
+		Manufacturer: "kratos",
+		Timeout:      duration(10 * time.Second),
+	}
+}
+
+// newFlagSet creates the flags, which are stored in the config given.  The
+// config's current values are the defaults.  Errors and usage are written to
+// the output.
+func newFlagSet(c *config, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("kratos", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&c.File, "config", c.File, "JSON file to load the configuration from.  Flags override the file.")
+	fs.StringVar(&c.Device, "device", c.Device, "Device name, such as mac:112233445566.")
+	fs.StringVar(&c.Firmware, "firmware", c.Firmware, "Firmware name sent to the server.")
+	fs.StringVar(&c.Model, "model", c.Model, "Model name sent to the server.")
+	fs.StringVar(&c.Manufacturer, "manufacturer", c.Manufacturer, "Manufacturer sent to the server.")
+	fs.StringVar(&c.URL, "url", c.URL, "Destination URL, such as http://localhost:6200/api/v2/device.")
+	fs.StringVar(&c.Token, "token", c.Token, "Bearer token to connect with.")
+	fs.Var((*stringList)(&c.CAFiles), "ca", "PEM file of certificate authorities to trust.  Can be repeated.")
+	fs.StringVar(&c.CertFile, "cert", c.CertFile, "PEM client certificate file, for mutual TLS.")
+	fs.StringVar(&c.KeyFile, "key", c.KeyFile, "PEM client key file, for mutual TLS.")
+	fs.BoolVar(&c.Insecure, "insecure", c.Insecure, "Skip verifying the server's certificate.")
+	fs.BoolVar(&c.Reconnect, "reconnect", c.Reconnect, "Reconnect when the connection is lost.")
+	fs.Var((*rules)(&c.Handlers), "handler", "Handler rule of the form regexp=action, where action is log, echo or status:<code>.  Can be repeated.")
+	fs.Var((*stringList)(&c.Send), "send", "JSON WRP message file to send after connecting.  Can be repeated.")
+	fs.BoolVar(&c.Stdin, "stdin", c.Stdin, "Send JSON WRP messages read from stdin, one per line.")
+	fs.BoolVar(&c.Once, "once", c.Once, "Exit after sending messages instead of waiting to be interrupted.")
+	fs.Var(&c.Timeout, "timeout", "Time allowed for sending each message.")
+	fs.BoolVar(&c.Verbose, "verbose", c.Verbose, "Log what the client is doing.")
+	fs.BoolVar(&c.REPL, "repl", c.REPL, "Compose and send messages interactively, after sending any files.")
+	fs.StringVar(&c.History, "history", c.History, "File to keep the repl's command history in.")
+	return fs
+}
+
+// parseConfig parses the command line, loading the config file if there is
+// one.
+func parseConfig(args []string, output io.Writer) (config, error) {
+	c := defaultConfig()
+	if err := newFlagSet(&c, output).Parse(args); err != nil {
+		return c, flagError{err}
+	}
+	if c.File != "" {
+		data, err := os.ReadFile(c.File)
+		if err != nil {
+			return c, err
+		}
+		file := c.File
+		c = defaultConfig()
+		if err = json.Unmarshal(data, &c); err != nil {
+			return c, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		// parse the flags again so they take precedence over the file.
+		if err = newFlagSet(&c, output).Parse(args); err != nil {
+			return c, flagError{err}
+		}
+	}
+	return c, c.validate()
+}
+
+// flagError is an error that the flag package has already printed, along
+// with the usage.
+type flagError struct {
+	error
+}
+
+func (e flagError) Unwrap() error {
+	return e.error
+}
+
+// validate checks that the config can be used to connect.
+func (c config) validate() error {
+	if c.Device == "" {
+		return errNoDevice
+	}
+	if _, err := wrp.ParseDeviceID(c.Device); err != nil {
+		return fmt.Errorf("invalid device name [%v]: %w", c.Device, err)
+	}
+	if c.URL == "" {
+		return errNoURL
+	}
+	if u, err := url.Parse(c.URL); err != nil || !slices.Contains([]string{"http", "https", "ws", "wss"}, u.Scheme) {
+		return fmt.Errorf("%w [%v]", errInvalidURL, c.URL)
+	}
+	if c.REPL && c.Stdin {
+		return errREPLStdin
+	}
+	for _, r := range c.Handlers {
+		if _, err := parseAction(r.Action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rule routes messages with destinations matching the regexp to an action.
+type rule struct {
+	Regexp string `json:"regexp"`
+	Action string `json:"action"`
+}
+
+// rules is a flag.Value for repeated handler rules.
+type rules []rule
+
+func (r *rules) String() string {
+	if r == nil {
+		return ""
+	}
+	s := make([]string, len(*r))
+	for i, rule := range *r {
+		s[i] = rule.Regexp + "=" + rule.Action
+	}
+	return strings.Join(s, ",")
+}
+
+func (r *rules) Set(value string) error {
+	// the regexp may contain '=', but the action can't.
+	i := strings.LastIndex(value, "=")
+	if i <= 0 {
+		return errInvalidRule
+	}
+	next := rule{Regexp: value[:i], Action: value[i+1:]}
+	if _, err := parseAction(next.Action); err != nil {
+		return err
+	}
+	*r = append(*r, next)
+	return nil
+}
+
+// stringList is a flag.Value for repeated strings.
+type stringList []string
+
+func (s *stringList) String() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// duration is a time.Duration that is written as a string, such as "10s", in
+// both flags and JSON.
+type duration time.Duration
+
+func (d *duration) String() string {
+	return time.Duration(*d).String()
+}
+
+func (d *duration) Set(value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return d.Set(s)
+}
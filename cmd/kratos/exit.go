@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/xmidt-org/kratos"
+)
+
+// Exit codes, so scripts can tell why kratos stopped.
+const (
+	exitOK                 = 0
+	exitError              = 1
+	exitUsage              = 2
+	exitConnectFailed      = 3
+	exitUnauthorized       = 4
+	exitRejected           = 5
+	exitServerError        = 6
+	exitDeviceDisconnected = 7
+	exitDeviceTimeout      = 8
+	exitSendFailed         = 9
+)
+
+// connectExitCode picks the exit code for a failure to connect, using the
+// status code the server responded with, if any.
+func connectExitCode(err error) int {
+	var coder kratos.StatusCoder
+	if !errors.As(err, &coder) {
+		return exitConnectFailed
+	}
+	switch code := coder.StatusCode(); {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return exitUnauthorized
+	case code == kratos.StatusDeviceDisconnected:
+		return exitDeviceDisconnected
+	case code == kratos.StatusDeviceTimeout:
+		return exitDeviceTimeout
+	case code >= 400 && code < 500:
+		return exitRejected
+	case code >= 500:
+		return exitServerError
+	default:
+		return exitConnectFailed
+	}
+}
@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xmidt-org/kratos"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+const (
+	logAction    = "log"
+	echoAction   = "echo"
+	statusPrefix = "status:"
+)
+
+// action decides how to respond to a message.  A nil response means no
+// response is sent.
+type action func(msg *wrp.Message) *wrp.Message
+
+// parseAction gets the action with the name given.
+func parseAction(name string) (action, error) {
+	switch {
+	case name == logAction:
+		return func(*wrp.Message) *wrp.Message { return nil }, nil
+	case name == echoAction:
+		return action(kratos.Echo()), nil
+	case strings.HasPrefix(name, statusPrefix):
+		code, err := strconv.ParseInt(strings.TrimPrefix(name, statusPrefix), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w [%v]: %w", errUnknownAction, name, err)
+		}
+		return action(kratos.ReplyWithStatus(code)), nil
+	default:
+		return nil, fmt.Errorf("%w [%v]", errUnknownAction, name)
+	}
+}
+
+// ruleHandler prints every message it is given, then follows its action.
+type ruleHandler struct {
+	printer *printer
+	action  action
+}
+
+// newHandlers creates the handlers for the rules.  Messages that match no
+// rule are still printed.
+func newHandlers(p *printer, rs []rule) ([]kratos.HandlerConfig, error) {
+	handlers := make([]kratos.HandlerConfig, 0, len(rs)+1)
+	for _, r := range rs {
+		a, err := parseAction(r.Action)
+		if err != nil {
+			return nil, err
+		}
+		handlers = append(handlers, kratos.HandlerConfig{
+			Regexp:  r.Regexp,
+			Handler: &ruleHandler{printer: p, action: a},
+		})
+	}
+	log, _ := parseAction(logAction)
+	handlers = append(handlers, kratos.HandlerConfig{
+		Regexp:   ".*",
+		Priority: -1,
+		Handler:  &ruleHandler{printer: p, action: log},
+	})
+	return handlers, nil
+}
+
+func (h *ruleHandler) HandleMessage(msg *wrp.Message) *wrp.Message {
+	h.printer.print("<-", msg)
+	response := h.action(msg)
+	if response != nil {
+		h.printer.print("->", response)
+	}
+	return response
+}
+
+func (h *ruleHandler) Close() {}
@@ -0,0 +1,244 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Command kratos emulates a device connected to XMiDT from the terminal.  It
+// prints every WRP message the device receives, responds to them following
+// handler rules, and sends messages read from files or stdin.  With -repl,
+// messages can be composed and sent interactively.
+//
+// The exit status tells why kratos stopped: 3 when the server could not be
+// reached, 4 when the device was not authorized, 5 when the connection was
+// otherwise rejected, 6 for a server error, 7 and 8 when the server reports
+// the device as disconnected or timed out, and 9 when a message could not be
+// sent.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/xmidt-org/kratos"
+	"github.com/xmidt-org/sallust"
+	"github.com/xmidt-org/wrp-go/v3"
+	"go.uber.org/zap"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run emulates the device until the context is done, until the messages are
+// sent when running once, or until the user quits the repl.  It returns the
+// exit code.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c, err := parseConfig(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		var fe flagError
+		if !errors.As(err, &fe) {
+			fmt.Fprintln(stderr, err)
+		}
+		return exitUsage
+	}
+
+	p := &printer{out: stdout}
+	config, err := newClientConfig(c, p)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	var h *history
+	if c.REPL {
+		if h, err = loadHistory(c.History); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		defer h.Close()
+		p.setPretty(true)
+	}
+
+	client, err := kratos.NewClient(config)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to connect: %v\n", err)
+		return connectExitCode(err)
+	}
+	defer client.Close()
+
+	s := &sender{client: client, config: c, printer: p, stderr: stderr}
+	sent := make(chan int, 1)
+	go func() {
+		code := s.sendAll(ctx, stdin)
+		if c.REPL {
+			r := &repl{client: client, device: c.Device, printer: p, history: h}
+			r.run(ctx, stdin)
+		}
+		sent <- code
+	}()
+
+	if c.Once || c.REPL {
+		select {
+		case code := <-sent:
+			return code
+		case <-ctx.Done():
+			return exitOK
+		}
+	}
+	<-ctx.Done()
+	select {
+	case code := <-sent:
+		return code
+	default:
+		return exitOK
+	}
+}
+
+// newClientConfig creates the kratos configuration for the device.
+func newClientConfig(c config, p *printer) (kratos.ClientConfig, error) {
+	handlers, err := newHandlers(p, c.Handlers)
+	if err != nil {
+		return kratos.ClientConfig{}, err
+	}
+
+	logger := zap.NewNop()
+	if c.Verbose {
+		logger = sallust.Default()
+	}
+
+	config := kratos.ClientConfig{
+		DeviceName:     c.Device,
+		FirmwareName:   c.Firmware,
+		ModelName:      c.Model,
+		Manufacturer:   c.Manufacturer,
+		DestinationURL: c.URL,
+		Handlers:       handlers,
+		HandlePingMiss: func() error {
+			p.printf("!! missed ping")
+			return nil
+		},
+		ClientLogger: logger,
+		Reconnect:    kratos.ReconnectConfig{Enabled: c.Reconnect},
+		Listeners: []kratos.ClientListener{kratos.ClientListenerFuncs{
+			Connect: func(e kratos.ConnectionEvent) {
+				p.printf("== connected to %s", e.URL)
+			},
+			Disconnect: func(e kratos.ConnectionEvent) {
+				if !errors.Is(e.Err, kratos.ErrClientClosed) {
+					p.printf("== disconnected: %v", e.Err)
+				}
+			},
+			Reconnect: func(e kratos.ConnectionEvent) {
+				if e.Err != nil {
+					p.printf("== reconnect attempt %d failed: %v", e.Attempt, e.Err)
+				}
+			},
+			Redirect: func(e kratos.ConnectionEvent) {
+				p.printf("== redirected to %s", e.Location)
+			},
+		}},
+	}
+	if len(c.CAFiles) > 0 || c.CertFile != "" || c.KeyFile != "" || c.Insecure {
+		config.TLS = &kratos.TLSConfig{
+			CertificateFile:    c.CertFile,
+			KeyFile:            c.KeyFile,
+			RootCAFiles:        c.CAFiles,
+			InsecureSkipVerify: c.Insecure,
+		}
+		// check the files now, so they aren't mistaken for failing to connect.
+		if _, err := config.TLS.NewTLSConfig(); err != nil {
+			return kratos.ClientConfig{}, err
+		}
+	}
+	if c.Token != "" {
+		token := kratos.Token{Value: c.Token}
+		config.TokenAcquirer = kratos.TokenAcquirerFunc(func(context.Context) (kratos.Token, error) {
+			return token, nil
+		})
+	}
+	return config, nil
+}
+
+// sender sends the messages from files and stdin.
+type sender struct {
+	client  kratos.Client
+	config  config
+	printer *printer
+	stderr  io.Writer
+}
+
+// sendAll sends every message, returning exitSendFailed if any failed.
+func (s *sender) sendAll(ctx context.Context, stdin io.Reader) int {
+	code := exitOK
+	send := func(name string, data []byte) {
+		if err := s.send(ctx, data); err != nil {
+			fmt.Fprintf(s.stderr, "failed to send %s: %v\n", name, err)
+			code = exitSendFailed
+		}
+	}
+
+	for _, file := range s.config.Send {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(s.stderr, "failed to read %s: %v\n", file, err)
+			code = exitSendFailed
+			continue
+		}
+		send(file, data)
+	}
+
+	if s.config.Stdin {
+		scanner := bufio.NewScanner(stdin)
+		for line := 1; scanner.Scan(); line++ {
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			send(fmt.Sprintf("stdin line %d", line), scanner.Bytes())
+		}
+		if err := scanner.Err(); err != nil {
+			fmt.Fprintf(s.stderr, "failed to read stdin: %v\n", err)
+			code = exitSendFailed
+		}
+	}
+	return code
+}
+
+// send decodes a JSON WRP message and sends it.  Requests with a transaction
+// id wait for the response, which is printed.
+func (s *sender) send(ctx context.Context, data []byte) error {
+	var msg wrp.Message
+	if err := wrp.NewDecoderBytes(data, wrp.JSON).Decode(&msg); err != nil {
+		return err
+	}
+	if msg.Type == wrp.Invalid0MessageType {
+		msg.Type = wrp.SimpleEventMessageType
+	}
+	if msg.Source == "" {
+		msg.Source = s.config.Device + "/kratos"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.config.Timeout))
+	defer cancel()
+	s.printer.print("->", &msg)
+	if msg.Type == wrp.SimpleRequestResponseMessageType && msg.TransactionUUID != "" {
+		response, err := s.client.Request(ctx, &msg)
+		if err != nil {
+			return err
+		}
+		s.printer.print("<-", response)
+		return nil
+	}
+	return s.client.SendContext(ctx, &msg)
+}
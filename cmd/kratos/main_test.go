@@ -0,0 +1,242 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/kratos"
+	"github.com/xmidt-org/kratos/kratostest"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+const deviceID = "mac:112233445566"
+
+// safeBuffer is a bytes.Buffer that can be written while it is being read.
+type safeBuffer struct {
+	buffer bytes.Buffer
+	lock   sync.Mutex
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *safeBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buffer.String()
+}
+
+func TestParseConfig(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	file := filepath.Join(t.TempDir(), "kratos.json")
+	require.NoError(os.WriteFile(file, []byte(`{
+		"device": "mac:112233445566",
+		"url": "http://localhost:6200",
+		"model": "from-file",
+		"handlers": [{"regexp": "/config", "action": "echo"}],
+		"timeout": "1s"
+	}`), 0600))
+
+	c, err := parseConfig([]string{"-config", file, "-url", "http://talaria:6200", "-handler", "/reboot=status:200"}, io.Discard)
+	require.NoError(err)
+	assert.Equal(deviceID, c.Device)
+	assert.Equal("http://talaria:6200", c.URL)
+	assert.Equal("from-file", c.Model)
+	assert.Equal("kratos", c.Firmware)
+	assert.Equal(duration(time.Second), c.Timeout)
+	assert.Equal([]rule{{Regexp: "/config", Action: "echo"}, {Regexp: "/reboot", Action: "status:200"}}, c.Handlers)
+
+	_, err = parseConfig([]string{"-url", "http://talaria:6200"}, io.Discard)
+	assert.ErrorIs(err, errNoDevice)
+
+	var fe flagError
+	_, err = parseConfig([]string{"-handler", "reboot"}, io.Discard)
+	assert.ErrorAs(err, &fe)
+}
+
+func TestRulesSet(t *testing.T) {
+	assert := assert.New(t)
+	var rs rules
+	assert.NoError(rs.Set("/a=b=echo"))
+	assert.Equal(rules{{Regexp: "/a=b", Action: "echo"}}, rs)
+	assert.ErrorIs(rs.Set("/a=reboot"), errUnknownAction)
+	assert.ErrorIs(rs.Set("/a=status:ok"), errUnknownAction)
+	assert.ErrorIs(rs.Set("echo"), errInvalidRule)
+}
+
+func TestConnectExitCode(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		expected   int
+	}{
+		{http.StatusUnauthorized, exitUnauthorized},
+		{http.StatusForbidden, exitUnauthorized},
+		{http.StatusBadRequest, exitRejected},
+		{http.StatusServiceUnavailable, exitServerError},
+		{kratos.StatusDeviceDisconnected, exitDeviceDisconnected},
+		{kratos.StatusDeviceTimeout, exitDeviceTimeout},
+	}
+	for _, tc := range tests {
+		t.Run(http.StatusText(tc.statusCode), func(t *testing.T) {
+			server := kratostest.NewServer()
+			defer server.Close()
+			server.FailNext(tc.statusCode)
+
+			var stderr bytes.Buffer
+			code := run(context.Background(), []string{"-device", deviceID, "-url", server.URL()}, nil, &bytes.Buffer{}, &stderr)
+			assert.Equal(t, tc.expected, code)
+			assert.Contains(t, stderr.String(), "failed to connect")
+		})
+	}
+
+	assert.Equal(t, exitConnectFailed, connectExitCode(errors.New("connection refused")))
+}
+
+func TestRunHistoryError(t *testing.T) {
+	server := kratostest.NewServer()
+	defer server.Close()
+
+	// a directory can't be used as the history file.
+	var stderr bytes.Buffer
+	code := run(context.Background(), []string{"-device", deviceID, "-url", server.URL(), "-repl", "-history", t.TempDir()}, nil, &bytes.Buffer{}, &stderr)
+	assert.Equal(t, exitError, code)
+	assert.NotEmpty(t, stderr.String())
+}
+
+func TestRunConfigErrors(t *testing.T) {
+	server := kratostest.NewServer()
+	defer server.Close()
+
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+	tests := []struct {
+		description string
+		args        []string
+	}{
+		{description: "device", args: []string{"-device", "not-a-device", "-url", server.URL()}},
+		{description: "url", args: []string{"-device", deviceID, "-url", "ftp://talaria"}},
+		{description: "cert", args: []string{"-device", deviceID, "-url", server.URL(), "-cert", missing, "-key", missing}},
+		{description: "ca", args: []string{"-device", deviceID, "-url", server.URL(), "-ca", missing}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var stderr bytes.Buffer
+			code := run(context.Background(), append(tc.args, "-once"), nil, &bytes.Buffer{}, &stderr)
+			assert.Equal(t, exitUsage, code)
+			assert.NotEmpty(t, stderr.String())
+			assert.NotContains(t, stderr.String(), "failed to connect")
+		})
+	}
+}
+
+func TestRunSend(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server := kratostest.NewServer(kratostest.WithHandler(func(_ wrp.DeviceID, msg *wrp.Message) *wrp.Message {
+		if msg.Type != wrp.SimpleRequestResponseMessageType {
+			return nil
+		}
+		return &wrp.Message{
+			Type:            msg.Type,
+			Source:          msg.Destination,
+			Destination:     msg.Source,
+			TransactionUUID: msg.TransactionUUID,
+			Payload:         []byte("pong"),
+		}
+	}))
+	defer server.Close()
+
+	stdin := strings.NewReader(`{"msg_type": 4, "dest": "event:device-status/online"}
+
+{"msg_type": 3, "dest": "dns:talaria/ping", "transaction_uuid": "ping"}
+not a message
+`)
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"-device", deviceID, "-url", server.URL(), "-stdin", "-once"}, stdin, &stdout, &stderr)
+	assert.Equal(exitSendFailed, code)
+	assert.Contains(stderr.String(), "stdin line 4")
+
+	received := server.Received()
+	require.Len(received, 2)
+	assert.Equal(deviceID+"/kratos", received[0].Source)
+	assert.Contains(stdout.String(), "<- SimpleRequestResponse dns:talaria/ping -> "+deviceID+"/kratos transaction=ping\n    pong")
+}
+
+func TestRunHandlers(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server := kratostest.NewServer()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var stdout safeBuffer
+	done := make(chan int, 1)
+	go func() {
+		done <- run(ctx, []string{"-device", deviceID, "-url", server.URL(), "-handler", "/config=echo"}, nil, &stdout, &bytes.Buffer{})
+	}()
+
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer waitCancel()
+	require.NoError(server.WaitForDevice(waitCtx, deviceID))
+
+	require.NoError(server.Send(deviceID, &wrp.Message{
+		Type:        wrp.SimpleEventMessageType,
+		Source:      "dns:talaria",
+		Destination: deviceID + "/config",
+		Payload:     []byte("hello"),
+	}))
+	require.NoError(server.Send(deviceID, &wrp.Message{
+		Type:        wrp.SimpleEventMessageType,
+		Source:      "dns:talaria",
+		Destination: deviceID + "/other",
+	}))
+
+	echo, err := server.WaitForMessage(waitCtx, func(msg *wrp.Message) bool {
+		return msg.Destination == "dns:talaria"
+	})
+	require.NoError(err)
+	assert.Equal([]byte("hello"), echo.Payload)
+	require.Eventually(func() bool {
+		return strings.Contains(stdout.String(), deviceID+"/other")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.Equal(exitOK, <-done)
+}
+
+func TestFormatMessage(t *testing.T) {
+	assert := assert.New(t)
+	status := int64(200)
+	assert.Equal("<- SimpleRequestResponse dns:talaria -> mac:112233445566/config transaction=abc status=200 content-type=text/plain\n    hi",
+		formatMessage("<-", &wrp.Message{
+			Type:            wrp.SimpleRequestResponseMessageType,
+			Source:          "dns:talaria",
+			Destination:     "mac:112233445566/config",
+			TransactionUUID: "abc",
+			Status:          &status,
+			ContentType:     "text/plain",
+			Payload:         []byte("hi"),
+		}))
+	assert.Equal("<3 bytes of binary>", formatPayload([]byte{0xff, 0xfe, 0xfd}))
+	assert.Contains(formatPayload(bytes.Repeat([]byte("a"), 2000)), "<2000 bytes>")
+}
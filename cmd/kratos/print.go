@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/ugorji/go/codec"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+const (
+	// Longest payload printed in full.
+	maxPrintedPayload = 1024
+
+	// Indent for everything printed after a message's first line.
+	indent = "    "
+)
+
+// printer writes messages to the terminal, one at a time.  Pretty printing
+// adds the rest of the message's fields and decodes JSON and msgpack
+// payloads.
+type printer struct {
+	out    io.Writer
+	pretty bool
+	lock   sync.Mutex
+}
+
+// print writes the message, with an arrow showing which way it went.
+func (p *printer) print(arrow string, msg *wrp.Message) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.pretty {
+		fmt.Fprintln(p.out, formatPretty(arrow, msg))
+		return
+	}
+	fmt.Fprintln(p.out, formatMessage(arrow, msg))
+}
+
+// printf writes a line of text.
+func (p *printer) printf(format string, args ...any) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	fmt.Fprintf(p.out, format+"\n", args...)
+}
+
+// write writes text without ending the line.
+func (p *printer) write(s string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	io.WriteString(p.out, s)
+}
+
+// setPretty turns pretty printing on or off.
+func (p *printer) setPretty(pretty bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.pretty = pretty
+}
+
+// formatMessage describes the message in a readable form.
+func formatMessage(arrow string, msg *wrp.Message) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s -> %s", arrow, msg.Type.FriendlyName(), msg.Source, msg.Destination)
+	if msg.TransactionUUID != "" {
+		fmt.Fprintf(&b, " transaction=%s", msg.TransactionUUID)
+	}
+	if msg.Status != nil {
+		fmt.Fprintf(&b, " status=%d", *msg.Status)
+	}
+	if msg.ContentType != "" {
+		fmt.Fprintf(&b, " content-type=%s", msg.ContentType)
+	}
+	if len(msg.Payload) > 0 {
+		b.WriteString("\n" + indent)
+		b.WriteString(formatPayload(msg.Payload))
+	}
+	return b.String()
+}
+
+// formatPretty describes every field of the message that is set, decoding
+// the payload when its content type is JSON or msgpack.
+func formatPretty(arrow string, msg *wrp.Message) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s -> %s", arrow, msg.Type.FriendlyName(), msg.Source, msg.Destination)
+	field := func(name string, value any) {
+		fmt.Fprintf(&b, "\n%s%s: %v", indent, name, value)
+	}
+	if msg.TransactionUUID != "" {
+		field("transaction", msg.TransactionUUID)
+	}
+	if msg.Status != nil {
+		field("status", *msg.Status)
+	}
+	if msg.ContentType != "" {
+		field("content-type", msg.ContentType)
+	}
+	if msg.Accept != "" {
+		field("accept", msg.Accept)
+	}
+	if msg.Path != "" {
+		field("path", msg.Path)
+	}
+	if msg.ServiceName != "" {
+		field("service", msg.ServiceName)
+	}
+	if msg.SessionID != "" {
+		field("session", msg.SessionID)
+	}
+	if msg.QualityOfService != 0 {
+		field("qos", msg.QualityOfService)
+	}
+	if len(msg.PartnerIDs) > 0 {
+		field("partners", strings.Join(msg.PartnerIDs, ", "))
+	}
+	for _, h := range msg.Headers {
+		field("header", h)
+	}
+	keys := make([]string, 0, len(msg.Metadata))
+	for k := range msg.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		field("metadata", k+"="+msg.Metadata[k])
+	}
+	if len(msg.Payload) > 0 {
+		b.WriteString("\n" + indent + "payload:\n" + indent + indent)
+		payload := decodePayload(msg.ContentType, msg.Payload)
+		b.WriteString(strings.ReplaceAll(payload, "\n", "\n"+indent+indent))
+	}
+	return b.String()
+}
+
+// formatPayload prints text payloads as they are, and summarizes binary or
+// very long ones.
+func formatPayload(payload []byte) string {
+	if !utf8.Valid(payload) {
+		return fmt.Sprintf("<%d bytes of binary>", len(payload))
+	}
+	if len(payload) > maxPrintedPayload {
+		return fmt.Sprintf("%s... <%d bytes>", payload[:maxPrintedPayload], len(payload))
+	}
+	return string(payload)
+}
+
+// decodePayload indents JSON payloads, and converts msgpack payloads to
+// indented JSON.  Anything else, or a payload that fails to decode, is
+// printed by formatPayload.
+func decodePayload(contentType string, payload []byte) string {
+	switch {
+	case strings.Contains(contentType, "json"):
+		var b bytes.Buffer
+		if json.Indent(&b, payload, "", "  ") == nil {
+			return b.String()
+		}
+	case strings.Contains(contentType, "msgpack"):
+		handle := codec.MsgpackHandle{}
+		handle.MapType = reflect.TypeOf(map[string]any(nil))
+		handle.RawToString = true
+		var v any
+		if codec.NewDecoderBytes(payload, &handle).Decode(&v) == nil {
+			if data, err := json.MarshalIndent(v, "", "  "); err == nil {
+				return string(data)
+			}
+		}
+	}
+	return formatPayload(payload)
+}
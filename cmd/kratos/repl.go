@@ -0,0 +1,425 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/xmidt-org/kratos"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+const prompt = "kratos> "
+
+var (
+	errUnknownCommand  = errors.New("unknown command, try help")
+	errUnknownTemplate = errors.New("unknown template, try templates")
+	errUnknownField    = errors.New("unknown field, try help")
+	errNoHistory       = errors.New("no such command in history")
+	errInvalidMetadata = errors.New("metadata must be key=value")
+	errQuit            = errors.New("quit")
+)
+
+// templates create drafts of common messages, sent from the device.
+var templates = map[string]func(device string) wrp.Message{
+	"event": func(device string) wrp.Message {
+		return wrp.Message{
+			Type:        wrp.SimpleEventMessageType,
+			Source:      device + "/kratos",
+			Destination: "event:device-status/" + device + "/kratos",
+			ContentType: "application/json",
+		}
+	},
+	"request": func(device string) wrp.Message {
+		return wrp.Message{
+			Type:            wrp.SimpleRequestResponseMessageType,
+			Source:          device + "/kratos",
+			Destination:     "dns:talaria",
+			TransactionUUID: uuid.NewString(),
+			ContentType:     "application/json",
+		}
+	},
+	"create":   crudTemplate(wrp.CreateMessageType),
+	"retrieve": crudTemplate(wrp.RetrieveMessageType),
+	"update":   crudTemplate(wrp.UpdateMessageType),
+	"delete":   crudTemplate(wrp.DeleteMessageType),
+	"registration": func(device string) wrp.Message {
+		return wrp.Message{
+			Type:        wrp.ServiceRegistrationMessageType,
+			ServiceName: "kratos",
+			URL:         "tcp://127.0.0.1:6666",
+		}
+	},
+}
+
+// crudTemplate creates a template for a CRUD message type.
+func crudTemplate(t wrp.MessageType) func(string) wrp.Message {
+	return func(device string) wrp.Message {
+		return wrp.Message{
+			Type:            t,
+			Source:          device + "/kratos",
+			Destination:     "dns:talaria",
+			TransactionUUID: uuid.NewString(),
+			Path:            "/",
+		}
+	}
+}
+
+// command is something that can be typed into the repl.
+type command struct {
+	name  string
+	usage string
+	run   func(r *repl, args string) error
+}
+
+// commands lists what can be typed into the repl, in the order help lists
+// them.
+func commands() []command {
+	return []command{
+		{"new", "new <template>\tstart a new message from a template", (*repl).newDraft},
+		{"set", "set <field> [value]\tset a field, or clear it without a value; lists are comma separated", (*repl).set},
+		{"add", "add <header|partner|metadata> <value>\tadd to a list field, metadata is key=value", (*repl).add},
+		{"show", "show\tprint the message", (*repl).show},
+		{"send", "send\tsend the message", (*repl).send},
+		{"json", "json <message>\tsend a message written as JSON", (*repl).sendJSON},
+		{"templates", "templates\tlist the templates", (*repl).listTemplates},
+		{"format", "format <text|pretty>\tchange how messages are printed", (*repl).format},
+		{"history", "history\tlist previous commands, which !<n> and !! run again", (*repl).listHistory},
+		{"help", "help\tlist the commands and fields", (*repl).help},
+		{"quit", "quit\tdisconnect and exit", func(*repl, string) error { return errQuit }},
+	}
+}
+
+// fields that can be set, and how to set them.
+var fields = map[string]func(msg *wrp.Message, value string) error{
+	"type": func(msg *wrp.Message, value string) error {
+		t := wrp.StringToMessageType(value)
+		if t == wrp.LastMessageType {
+			return fmt.Errorf("unknown message type [%v]", value)
+		}
+		msg.Type = t
+		return nil
+	},
+	"source":       func(msg *wrp.Message, value string) error { msg.Source = value; return nil },
+	"dest":         func(msg *wrp.Message, value string) error { msg.Destination = value; return nil },
+	"transaction":  func(msg *wrp.Message, value string) error { msg.TransactionUUID = value; return nil },
+	"content-type": func(msg *wrp.Message, value string) error { msg.ContentType = value; return nil },
+	"accept":       func(msg *wrp.Message, value string) error { msg.Accept = value; return nil },
+	"path":         func(msg *wrp.Message, value string) error { msg.Path = value; return nil },
+	"service":      func(msg *wrp.Message, value string) error { msg.ServiceName = value; return nil },
+	"session":      func(msg *wrp.Message, value string) error { msg.SessionID = value; return nil },
+	"url":          func(msg *wrp.Message, value string) error { msg.URL = value; return nil },
+	"status": func(msg *wrp.Message, value string) error {
+		if value == "" {
+			msg.Status = nil
+			return nil
+		}
+		status, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		msg.SetStatus(status)
+		return nil
+	},
+	"qos": func(msg *wrp.Message, value string) error {
+		if value == "" {
+			msg.QualityOfService = 0
+			return nil
+		}
+		qos, err := strconv.Atoi(value)
+		msg.QualityOfService = wrp.QOSValue(qos)
+		return err
+	},
+	"partners": func(msg *wrp.Message, value string) error {
+		msg.PartnerIDs = nil
+		if value != "" {
+			msg.PartnerIDs = strings.Split(value, ",")
+		}
+		return nil
+	},
+	"headers": func(msg *wrp.Message, value string) error {
+		msg.Headers = nil
+		if value != "" {
+			msg.Headers = strings.Split(value, ",")
+		}
+		return nil
+	},
+	"metadata": func(msg *wrp.Message, value string) error {
+		msg.Metadata = nil
+		if value == "" {
+			return nil
+		}
+		msg.Metadata = make(map[string]string)
+		for _, pair := range strings.Split(value, ",") {
+			k, v, ok := strings.Cut(pair, "=")
+			if !ok {
+				return errInvalidMetadata
+			}
+			msg.Metadata[k] = v
+		}
+		return nil
+	},
+	"payload": func(msg *wrp.Message, value string) error {
+		if file, ok := strings.CutPrefix(value, "@"); ok {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			msg.Payload = data
+			return nil
+		}
+		msg.Payload = []byte(value)
+		return nil
+	},
+}
+
+// repl lets a user compose messages, send them, and watch the responses
+// arrive through the registry.
+type repl struct {
+	client  kratos.Client
+	device  string
+	printer *printer
+	history *history
+	draft   wrp.Message
+}
+
+// run reads commands until the input ends, the user quits, or the context
+// is done.
+func (r *repl) run(ctx context.Context, in io.Reader) {
+	r.draft = templates["event"](r.device)
+	r.printer.printf("Type help for the commands.  The message being composed starts from the event template.")
+	scanner := bufio.NewScanner(in)
+	for {
+		r.printer.write(prompt)
+		if !scanner.Scan() || ctx.Err() != nil {
+			return
+		}
+		line, err := r.history.expand(strings.TrimSpace(scanner.Text()))
+		if err != nil {
+			r.printer.printf("error: %v", err)
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		r.history.add(line)
+		if err = r.exec(line); errors.Is(err, errQuit) {
+			return
+		} else if err != nil {
+			r.printer.printf("error: %v", err)
+		}
+	}
+}
+
+// exec runs one command.
+func (r *repl) exec(line string) error {
+	name, args, _ := strings.Cut(line, " ")
+	if name == "exit" {
+		name = "quit"
+	}
+	for _, c := range commands() {
+		if c.name == name {
+			return c.run(r, strings.TrimSpace(args))
+		}
+	}
+	return fmt.Errorf("%w [%v]", errUnknownCommand, name)
+}
+
+func (r *repl) newDraft(name string) error {
+	template, ok := templates[name]
+	if !ok {
+		return fmt.Errorf("%w [%v]", errUnknownTemplate, name)
+	}
+	r.draft = template(r.device)
+	return r.show("")
+}
+
+func (r *repl) set(args string) error {
+	name, value, _ := strings.Cut(args, " ")
+	setField, ok := fields[name]
+	if !ok {
+		return fmt.Errorf("%w [%v]", errUnknownField, name)
+	}
+	return setField(&r.draft, strings.TrimSpace(value))
+}
+
+func (r *repl) add(args string) error {
+	name, value, _ := strings.Cut(args, " ")
+	value = strings.TrimSpace(value)
+	switch name {
+	case "header":
+		r.draft.Headers = append(r.draft.Headers, value)
+	case "partner":
+		r.draft.PartnerIDs = append(r.draft.PartnerIDs, value)
+	case "metadata":
+		k, v, ok := strings.Cut(value, "=")
+		if !ok {
+			return errInvalidMetadata
+		}
+		if r.draft.Metadata == nil {
+			r.draft.Metadata = make(map[string]string)
+		}
+		r.draft.Metadata[k] = v
+	default:
+		return fmt.Errorf("%w [%v]", errUnknownField, name)
+	}
+	return nil
+}
+
+func (r *repl) show(string) error {
+	r.printer.printf("%s", formatPretty("..", &r.draft))
+	return nil
+}
+
+func (r *repl) send(string) error {
+	msg := copyMessage(&r.draft)
+	r.printer.print("->", msg)
+	r.client.Send(msg)
+	return nil
+}
+
+// copyMessage copies the message deeply enough that later changes to it
+// don't reach the copy, which the client encodes while the user keeps
+// editing the draft.
+func copyMessage(msg *wrp.Message) *wrp.Message {
+	c := *msg
+	c.PartnerIDs = slices.Clone(msg.PartnerIDs)
+	c.Headers = slices.Clone(msg.Headers)
+	c.Metadata = maps.Clone(msg.Metadata)
+	c.Payload = slices.Clone(msg.Payload)
+	return &c
+}
+
+func (r *repl) sendJSON(args string) error {
+	var msg wrp.Message
+	if err := wrp.NewDecoderBytes([]byte(args), wrp.JSON).Decode(&msg); err != nil {
+		return err
+	}
+	r.printer.print("->", &msg)
+	r.client.Send(&msg)
+	return nil
+}
+
+func (r *repl) listTemplates(string) error {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	r.printer.printf("%s", strings.Join(names, " "))
+	return nil
+}
+
+func (r *repl) format(args string) error {
+	switch args {
+	case "text":
+		r.printer.setPretty(false)
+	case "pretty":
+		r.printer.setPretty(true)
+	default:
+		return fmt.Errorf("unknown format [%v]", args)
+	}
+	return nil
+}
+
+func (r *repl) listHistory(string) error {
+	for i, line := range r.history.lines {
+		r.printer.printf("%4d  %s", i+1, line)
+	}
+	return nil
+}
+
+func (r *repl) help(string) error {
+	var b strings.Builder
+	for _, c := range commands() {
+		usage, description, _ := strings.Cut(c.usage, "\t")
+		fmt.Fprintf(&b, "  %-40s %s\n", usage, description)
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(&b, "Fields: %s\n", strings.Join(names, " "))
+	b.WriteString("A payload of @<file> is read from the file.")
+	r.printer.printf("%s", b.String())
+	return nil
+}
+
+// history remembers the commands run, optionally saving them to a file so
+// they are available the next time.
+type history struct {
+	lines []string
+	file  io.Writer
+}
+
+// loadHistory reads the history file, creating it if needed.  With no file,
+// history only lasts until exiting.
+func loadHistory(path string) (*history, error) {
+	h := &history{}
+	if path == "" {
+		return h, nil
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if line != "" {
+				h.lines = append(h.lines, line)
+			}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	h.file = file
+	return h, nil
+}
+
+// add remembers a command.
+func (h *history) add(line string) {
+	h.lines = append(h.lines, line)
+	if h.file != nil {
+		fmt.Fprintln(h.file, line)
+	}
+}
+
+// expand replaces !! with the last command and !<n> with the nth command.
+func (h *history) expand(line string) (string, error) {
+	if !strings.HasPrefix(line, "!") {
+		return line, nil
+	}
+	i := len(h.lines)
+	if line != "!!" {
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("%w [%v]", errNoHistory, line)
+		}
+		i = n
+	}
+	if i < 1 || i > len(h.lines) {
+		return "", fmt.Errorf("%w [%v]", errNoHistory, line)
+	}
+	return h.lines[i-1], nil
+}
+
+// Close closes the history file.
+func (h *history) Close() error {
+	if c, ok := h.file.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+	"github.com/xmidt-org/kratos"
+	"github.com/xmidt-org/kratos/kratostest"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+func TestREPL(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server := kratostest.NewServer()
+	defer server.Close()
+
+	historyFile := filepath.Join(t.TempDir(), "history")
+	require.NoError(os.WriteFile(historyFile, []byte("templates\n"), 0600))
+
+	stdin := strings.NewReader(strings.Join([]string{
+		"new update",
+		"set dest mac:112233445566/config",
+		"set payload {\"enabled\":true}",
+		"set content-type application/json",
+		"add partner comcast",
+		"add metadata /boot-time=1",
+		"send",
+		"!!",
+		"set qos high",
+		"bogus",
+		"history",
+		"quit",
+		"send",
+	}, "\n"))
+	var stdout bytes.Buffer
+	code := run(context.Background(), []string{"-device", deviceID, "-url", server.URL(), "-repl", "-history", historyFile}, stdin, &stdout, &bytes.Buffer{})
+	assert.Equal(exitOK, code)
+
+	// the client sends asynchronously, so the server may still be reading.
+	require.Eventually(func() bool {
+		return len(server.Received()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	msg := server.Received()[0]
+	assert.Equal(wrp.UpdateMessageType, msg.Type)
+	assert.Equal("mac:112233445566/config", msg.Destination)
+	assert.Equal([]string{"comcast"}, msg.PartnerIDs)
+	assert.Equal(map[string]string{"/boot-time": "1"}, msg.Metadata)
+	assert.Equal(`{"enabled":true}`, string(msg.Payload))
+
+	output := stdout.String()
+	assert.Contains(output, "-> Update mac:112233445566/kratos -> mac:112233445566/config")
+	assert.Contains(output, "        \"enabled\": true")
+	assert.Contains(output, "error: strconv.Atoi")
+	assert.Contains(output, "error: unknown command, try help [bogus]")
+	assert.Contains(output, "   1  templates")
+	assert.Contains(output, "   9  send")
+
+	data, err := os.ReadFile(historyFile)
+	require.NoError(err)
+	assert.True(strings.HasSuffix(string(data), "history\nquit\n"))
+}
+
+func TestHistoryExpand(t *testing.T) {
+	assert := assert.New(t)
+	h := &history{lines: []string{"show", "send"}}
+
+	line, err := h.expand("!!")
+	assert.NoError(err)
+	assert.Equal("send", line)
+
+	line, err = h.expand("!1")
+	assert.NoError(err)
+	assert.Equal("show", line)
+
+	_, err = h.expand("!3")
+	assert.ErrorIs(err, errNoHistory)
+	_, err = h.expand("!x")
+	assert.ErrorIs(err, errNoHistory)
+
+	line, err = h.expand("show")
+	assert.NoError(err)
+	assert.Equal("show", line)
+}
+
+func TestREPLSet(t *testing.T) {
+	assert := assert.New(t)
+	r := &repl{device: deviceID}
+	r.draft = templates["request"](deviceID)
+	assert.NotEmpty(r.draft.TransactionUUID)
+
+	assert.NoError(r.set("type Retrieve"))
+	assert.Equal(wrp.RetrieveMessageType, r.draft.Type)
+	assert.Error(r.set("type Nothing"))
+
+	assert.NoError(r.set("status 200"))
+	assert.Equal(int64(200), *r.draft.Status)
+	assert.NoError(r.set("status"))
+	assert.Nil(r.draft.Status)
+
+	assert.NoError(r.set("headers a,b"))
+	assert.Equal([]string{"a", "b"}, r.draft.Headers)
+	assert.NoError(r.add("header c"))
+	assert.Equal([]string{"a", "b", "c"}, r.draft.Headers)
+
+	assert.ErrorIs(r.set("metadata a"), errInvalidMetadata)
+	assert.ErrorIs(r.add("metadata a"), errInvalidMetadata)
+	assert.ErrorIs(r.set("nothing 1"), errUnknownField)
+	assert.ErrorIs(r.add("nothing 1"), errUnknownField)
+	assert.ErrorIs(r.newDraft("nothing"), errUnknownTemplate)
+}
+
+// sentClient remembers the messages sent through it.
+type sentClient struct {
+	kratos.Client
+	sent []*wrp.Message
+}
+
+func (s *sentClient) Send(msg *wrp.Message) {
+	s.sent = append(s.sent, msg)
+}
+
+func TestREPLSendCopies(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &sentClient{}
+	r := &repl{client: client, device: deviceID, printer: &printer{out: &bytes.Buffer{}}}
+	r.draft = templates["event"](deviceID)
+	assert.NoError(r.add("partner comcast"))
+	assert.NoError(r.add("metadata /boot-time=1"))
+	assert.NoError(r.set("payload hello"))
+	assert.NoError(r.send(""))
+
+	// editing the draft doesn't change what was already sent.
+	r.draft.PartnerIDs[0] = "sky"
+	assert.NoError(r.add("metadata /boot-time=2"))
+	r.draft.Payload[0] = 'j'
+
+	sent := client.sent[0]
+	assert.Equal([]string{"comcast"}, sent.PartnerIDs)
+	assert.Equal(map[string]string{"/boot-time": "1"}, sent.Metadata)
+	assert.Equal("hello", string(sent.Payload))
+}
+
+func TestDecodePayload(t *testing.T) {
+	assert := assert.New(t)
+
+	var payload []byte
+	require.NoError(t, codec.NewEncoderBytes(&payload, &codec.MsgpackHandle{}).Encode(map[string]any{"uptime": 5}))
+	assert.Equal("{\n  \"uptime\": 5\n}", decodePayload("application/msgpack", payload))
+
+	assert.Equal("{\n  \"a\": 1\n}", decodePayload("application/json", []byte(`{"a":1}`)))
+	assert.Equal("{bad", decodePayload("application/json", []byte(`{bad`)))
+	assert.Equal("plain", decodePayload("text/plain", []byte("plain")))
+}
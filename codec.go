@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"sync"
+
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+// codecs is a pool of msgpack encoders and decoders that are reset and reused
+// for every message, instead of being created for each one.  A Fleet shares
+// one codecs between the encoder and decoder queues of all its devices.
+type codecs struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+// newCodecs creates an empty pool of encoders and decoders.
+func newCodecs() *codecs {
+	return &codecs{
+		encoders: sync.Pool{
+			New: func() any {
+				var data []byte
+				return wrp.NewEncoderBytes(&data, wrp.Msgpack)
+			},
+		},
+		decoders: sync.Pool{
+			New: func() any { return wrp.NewDecoderBytes(nil, wrp.Msgpack) },
+		},
+	}
+}
+
+// encode encodes the message using a pooled encoder.  The bytes returned are
+// not shared with the pool.
+func (c *codecs) encode(msg *wrp.Message) ([]byte, error) {
+	encoder := c.encoders.Get().(wrp.Encoder)
+	defer c.encoders.Put(encoder)
+
+	var data []byte
+	encoder.ResetBytes(&data)
+	err := encoder.Encode(msg)
+	return data, err
+}
+
+// decode decodes the data into the message using a pooled decoder.
+func (c *codecs) decode(data []byte, msg *wrp.Message) error {
+	decoder := c.decoders.Get().(wrp.Decoder)
+	defer c.decoders.Put(decoder)
+
+	decoder.ResetBytes(data)
+	err := decoder.Decode(msg)
+	decoder.ResetBytes(nil)
+	return err
+}
@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+func TestCodecs(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newCodecs()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msg := &wrp.Message{
+				Type:        wrp.SimpleEventMessageType,
+				Source:      "mac:ffffff112233/emu",
+				Destination: fmt.Sprintf("event:device-status/%d", i),
+				Payload:     []byte("payload"),
+			}
+			data, err := c.encode(msg)
+			assert.NoError(err)
+
+			var decoded wrp.Message
+			assert.NoError(c.decode(data, &decoded))
+			assert.Equal(msg.Destination, decoded.Destination)
+			assert.Equal(msg.Payload, decoded.Payload)
+		}()
+	}
+	wg.Wait()
+
+	assert.Error(c.decode([]byte("not msgpack"), &wrp.Message{}))
+}
@@ -0,0 +1,329 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/xmidt-org/wrp-go/v3"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	errRequired           = errors.New("value is required")
+	errNegative           = errors.New("value must not be negative")
+	errMultiplier         = errors.New("value must be at least 1")
+	errJitter             = errors.New("value must be between 0 and 1")
+	errUnboundHandler     = errors.New("no handler is bound to the name")
+	errRedirectCode       = errors.New("value must be a 3xx status code")
+	errUnknownMessageType = errors.New("unknown message type")
+)
+
+// Config is the serializable form of ClientConfig.  It can be loaded from
+// YAML or JSON with LoadConfig, and overridden by environment variables with
+// ApplyEnv.  Handlers are referred to by name, and bound to the handlers
+// themselves by ClientConfig.
+type Config struct {
+	DeviceName       string            `yaml:"deviceName"`
+	FirmwareName     string            `yaml:"firmwareName"`
+	ModelName        string            `yaml:"modelName"`
+	Manufacturer     string            `yaml:"manufacturer"`
+	BootTime         time.Time         `yaml:"bootTime"`
+	LastRebootReason string            `yaml:"lastRebootReason"`
+	InterfaceUsed    string            `yaml:"interfaceUsed"`
+	Protocol         string            `yaml:"protocol"`
+	UserAgent        string            `yaml:"userAgent"`
+	Convey           map[string]any    `yaml:"convey"`
+	Headers          map[string]string `yaml:"headers"`
+	DestinationURL   string            `yaml:"destinationURL"`
+	Queues           QueuesConfig      `yaml:"queues"`
+	Ping             PingConfig        `yaml:"ping"`
+	Write            WriteConfig       `yaml:"write"`
+	Reconnect        ReconnectConfig   `yaml:"reconnect"`
+	TLS              *TLSConfig        `yaml:"tls"`
+	Dialer           DialerConfig      `yaml:"dialer"`
+	Redirect         RedirectPolicy    `yaml:"redirect"`
+	HandlerOrder     HandlerOrder      `yaml:"handlerOrder"`
+	Handlers         []RouteConfig     `yaml:"handlers"`
+}
+
+// QueuesConfig configures the queue of each Stage.
+type QueuesConfig struct {
+	Outbound QueueConfig `yaml:"outbound"`
+	Encoder  QueueConfig `yaml:"encoder"`
+	Decoder  QueueConfig `yaml:"decoder"`
+	Registry QueueConfig `yaml:"registry"`
+	Handler  QueueConfig `yaml:"handler"`
+}
+
+// RouteConfig routes messages with destinations matching Regexp to the
+// handler bound to the name Handler.  Types, Source and Destination narrow
+// the messages routed, and are only checked when set.  Types are WRP message
+// type names, such as SimpleEvent or Retrieve.  Name identifies the route,
+// and is needed when routes share a Regexp.
+type RouteConfig struct {
+	Name        string        `yaml:"name"`
+	Regexp      string        `yaml:"regexp"`
+	Types       []string      `yaml:"types"`
+	Source      LocatorMatch  `yaml:"source"`
+	Destination LocatorMatch  `yaml:"destination"`
+	Handler     string        `yaml:"handler"`
+	Priority    int           `yaml:"priority"`
+	Timeout     time.Duration `yaml:"timeout"`
+}
+
+// matchers creates the Matchers for the route's Types, Source and
+// Destination.
+func (r RouteConfig) matchers() ([]Matcher, error) {
+	var matchers []Matcher
+	if len(r.Types) > 0 {
+		types := make([]wrp.MessageType, 0, len(r.Types))
+		for _, name := range r.Types {
+			t := wrp.StringToMessageType(name)
+			if t == wrp.LastMessageType {
+				return nil, fmt.Errorf("%w [%v]", errUnknownMessageType, name)
+			}
+			types = append(types, t)
+		}
+		matchers = append(matchers, MatchTypes(types...))
+	}
+	if r.Source != (LocatorMatch{}) {
+		matchers = append(matchers, MatchSource(r.Source))
+	}
+	if r.Destination != (LocatorMatch{}) {
+		matchers = append(matchers, MatchDestination(r.Destination))
+	}
+	return matchers, nil
+}
+
+// ConfigError is a problem with one field of a Config.  Field is the path to
+// the field, such as queues.encoder.overflow, or the environment variable the
+// value came from.
+type ConfigError struct {
+	Field string
+	Err   error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// LoadConfig reads a Config from a YAML or JSON file.  Unknown fields are
+// rejected.  The Config is not validated, so that it can still be changed,
+// such as by ApplyEnv.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	c, err := ParseConfig(data)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// ParseConfig reads a Config from YAML or JSON, which is also YAML.  Durations
+// are written as strings, such as "10s".
+func ParseConfig(data []byte) (Config, error) {
+	var c Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+// Validate checks every field of the Config, returning a *ConfigError for
+// each problem found.
+func (c Config) Validate() error {
+	var errs errorList
+	check := func(field string, err error) {
+		if err != nil {
+			errs = append(errs, &ConfigError{Field: field, Err: err})
+		}
+	}
+
+	if c.DeviceName == "" {
+		check("deviceName", errRequired)
+	} else {
+		_, err := wrp.ParseDeviceID(c.DeviceName)
+		check("deviceName", err)
+	}
+	if c.DestinationURL == "" {
+		check("destinationURL", errRequired)
+	} else {
+		_, err := websocketURL(c.DestinationURL)
+		check("destinationURL", err)
+	}
+
+	queues := []struct {
+		name   string
+		config QueueConfig
+	}{
+		{"outbound", c.Queues.Outbound},
+		{"encoder", c.Queues.Encoder},
+		{"decoder", c.Queues.Decoder},
+		{"registry", c.Queues.Registry},
+		{"handler", c.Queues.Handler},
+	}
+	for _, q := range queues {
+		check("queues."+q.name+".maxWorkers", notNegative(q.config.MaxWorkers))
+		check("queues."+q.name+".size", notNegative(q.config.Size))
+		check("queues."+q.name+".overflow", q.config.Overflow.validate())
+		check("queues."+q.name+".timeout", notNegative(q.config.Timeout))
+	}
+
+	check("ping.pingWait", notNegative(c.Ping.PingWait))
+	check("ping.maxPingMiss", notNegative(c.Ping.MaxPingMiss))
+	check("write.timeout", notNegative(c.Write.Timeout))
+	check("write.messagesPerDeadline", notNegative(c.Write.MessagesPerDeadline))
+
+	check("reconnect.initialInterval", notNegative(c.Reconnect.InitialInterval))
+	check("reconnect.maxInterval", notNegative(c.Reconnect.MaxInterval))
+	check("reconnect.maxElapsedTime", notNegative(c.Reconnect.MaxElapsedTime))
+	if c.Reconnect.Multiplier != 0 && c.Reconnect.Multiplier < 1 {
+		check("reconnect.multiplier", errMultiplier)
+	}
+	if c.Reconnect.Jitter < 0 || c.Reconnect.Jitter > 1 {
+		check("reconnect.jitter", errJitter)
+	}
+
+	if c.TLS != nil && (c.TLS.CertificateFile == "") != (c.TLS.KeyFile == "") {
+		check("tls.keyFile", errIncompleteKeyPair)
+	}
+
+	check("dialer.handshakeTimeout", notNegative(c.Dialer.HandshakeTimeout))
+	check("dialer.connectTimeout", notNegative(c.Dialer.ConnectTimeout))
+	check("dialer.readBufferSize", notNegative(c.Dialer.ReadBufferSize))
+	check("dialer.writeBufferSize", notNegative(c.Dialer.WriteBufferSize))
+	if c.Dialer.Proxy != "" {
+		_, err := url.Parse(c.Dialer.Proxy)
+		check("dialer.proxy", err)
+	}
+	if c.Dialer.LocalAddr != "" {
+		if c.Dialer.UnixSocket != "" {
+			check("dialer.localAddr", errUnixLocalAddr)
+		} else {
+			_, err := parseLocalAddr(c.Dialer.LocalAddr)
+			check("dialer.localAddr", err)
+		}
+	}
+	for i, code := range c.Redirect.StatusCodes {
+		if code < 300 || code > 399 {
+			check(fmt.Sprintf("redirect.statusCodes[%d]", i), errRedirectCode)
+		}
+	}
+
+	_, err := encodeConvey(c.Convey)
+	check("convey", err)
+
+	check("handlerOrder", c.HandlerOrder.validate())
+	for i, route := range c.Handlers {
+		field := fmt.Sprintf("handlers[%d]", i)
+		_, err := regexp.Compile(route.Regexp)
+		check(field+".regexp", err)
+		if route.Handler == "" {
+			check(field+".handler", errRequired)
+		}
+		check(field+".timeout", notNegative(route.Timeout))
+		_, err = route.matchers()
+		check(field+".types", err)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// notNegative checks that a number is not negative.
+func notNegative[T ~int | ~int64](value T) error {
+	if value < 0 {
+		return errNegative
+	}
+	return nil
+}
+
+// ClientConfig validates the Config and converts it to a ClientConfig.  Each
+// handler route is bound to the handler in handlers with the same name.
+// Values that can't be serialized, such as HandlePingMiss, still need to be
+// set on the ClientConfig returned.
+func (c Config) ClientConfig(handlers map[string]DownstreamHandler) (ClientConfig, error) {
+	if err := c.Validate(); err != nil {
+		return ClientConfig{}, err
+	}
+
+	config := ClientConfig{
+		DeviceName:           c.DeviceName,
+		FirmwareName:         c.FirmwareName,
+		ModelName:            c.ModelName,
+		Manufacturer:         c.Manufacturer,
+		BootTime:             c.BootTime,
+		LastRebootReason:     c.LastRebootReason,
+		InterfaceUsed:        c.InterfaceUsed,
+		Protocol:             c.Protocol,
+		UserAgent:            c.UserAgent,
+		Convey:               c.Convey,
+		DestinationURL:       c.DestinationURL,
+		OutboundQueue:        c.Queues.Outbound,
+		WRPEncoderQueue:      c.Queues.Encoder,
+		WRPDecoderQueue:      c.Queues.Decoder,
+		HandlerRegistryQueue: c.Queues.Registry,
+		HandleMsgQueue:       c.Queues.Handler,
+		HandlerOrder:         c.HandlerOrder,
+		PingConfig:           c.Ping,
+		WriteConfig:          c.Write,
+		Reconnect:            c.Reconnect,
+		TLS:                  c.TLS,
+		DialerConfig:         c.Dialer,
+		Redirect:             c.Redirect,
+	}
+
+	for name, value := range c.Headers {
+		if config.Headers == nil {
+			config.Headers = make(http.Header, len(c.Headers))
+		}
+		config.Headers.Set(name, value)
+	}
+
+	var errs errorList
+	for i, route := range c.Handlers {
+		handler, ok := handlers[route.Handler]
+		if !ok {
+			errs = append(errs, &ConfigError{
+				Field: fmt.Sprintf("handlers[%d].handler", i),
+				Err:   fmt.Errorf("%w [%v]", errUnboundHandler, route.Handler),
+			})
+			continue
+		}
+		// the route was validated, so its matchers can be made.
+		matchers, _ := route.matchers()
+		config.Handlers = append(config.Handlers, HandlerConfig{
+			Name:     route.Name,
+			Regexp:   route.Regexp,
+			Match:    matchers,
+			Handler:  handler,
+			Priority: route.Priority,
+			Timeout:  route.Timeout,
+		})
+	}
+	if len(errs) > 0 {
+		return ClientConfig{}, errs
+	}
+	return config, nil
+}
@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DefaultEnvPrefix is the prefix commonly given to ApplyEnv.
+const DefaultEnvPrefix = "KRATOS"
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// ApplyEnv overrides the Config with environment variables.  Each variable is
+// named by the prefix and the path to the field in upper snake case, such as
+// KRATOS_DEVICE_NAME or KRATOS_QUEUES_ENCODER_MAX_WORKERS for the prefix
+// KRATOS.  Lists of strings are comma separated, and times are RFC 3339.
+// Handlers, the convey and extra headers can't be set from the environment.
+func (c *Config) ApplyEnv(prefix string) error {
+	_, err := applyEnv(reflect.ValueOf(c).Elem(), prefix, os.LookupEnv)
+	return err
+}
+
+// applyEnv sets the value, and any fields in it, from the environment
+// variables starting with name.  It reports whether anything was set.
+func applyEnv(v reflect.Value, name string, lookup func(string) (string, bool)) (bool, error) {
+	switch {
+	case v.Type() == timeType:
+		// times are set whole, rather than field by field.
+	case v.Kind() == reflect.Struct:
+		set := false
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			tag, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if !field.IsExported() || tag == "-" || tag == "" {
+				continue
+			}
+			fieldSet, err := applyEnv(v.Field(i), name+"_"+envName(tag), lookup)
+			if err != nil {
+				return false, err
+			}
+			set = set || fieldSet
+		}
+		return set, nil
+	case v.Kind() == reflect.Pointer:
+		// only keep a new value if something in it was set.
+		elem := reflect.New(v.Type().Elem())
+		if !v.IsNil() {
+			elem.Elem().Set(v.Elem())
+		}
+		set, err := applyEnv(elem.Elem(), name, lookup)
+		if set {
+			v.Set(elem)
+		}
+		return set, err
+	case v.Kind() == reflect.Map:
+		return false, nil
+	case v.Kind() == reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return false, nil
+		}
+		value, ok := lookup(name)
+		if !ok {
+			return false, nil
+		}
+		values := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, s := range strings.Split(value, ",") {
+			values = reflect.Append(values, reflect.ValueOf(strings.TrimSpace(s)).Convert(v.Type().Elem()))
+		}
+		v.Set(values)
+		return true, nil
+	}
+
+	value, ok := lookup(name)
+	if !ok {
+		return false, nil
+	}
+	if err := setScalar(v, value); err != nil {
+		return false, &ConfigError{Field: name, Err: err}
+	}
+	return true, nil
+}
+
+// setScalar parses the string into the value.  Times are RFC 3339.
+func setScalar(v reflect.Value, value string) error {
+	if v.Type() == timeType {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == durationType {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	}
+	return nil
+}
+
+// envName converts a camel case field name, such as rootCAFiles, to upper
+// snake case, such as ROOT_CA_FILES.
+func envName(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
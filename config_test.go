@@ -0,0 +1,271 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+const yamlConfig = `
+deviceName: mac:112233445566
+firmwareName: firmware
+destinationURL: https://talaria:6200/api/v2/device
+queues:
+  encoder:
+    maxWorkers: 5
+    overflow: drop-oldest
+ping:
+  pingWait: 90s
+reconnect:
+  enabled: true
+  initialInterval: 2s
+  jitter: 0.5
+tls:
+  rootCAFiles: [ca.pem]
+dialer:
+  localAddr: 10.0.0.2
+handlers:
+  - regexp: /config
+    handler: config
+    priority: 1
+`
+
+const jsonConfig = `{
+	"deviceName": "mac:112233445566",
+	"firmwareName": "firmware",
+	"destinationURL": "https://talaria:6200/api/v2/device",
+	"queues": {"encoder": {"maxWorkers": 5, "overflow": "drop-oldest"}},
+	"ping": {"pingWait": "90s"},
+	"reconnect": {"enabled": true, "initialInterval": "2s", "jitter": 0.5},
+	"tls": {"rootCAFiles": ["ca.pem"]},
+	"dialer": {"localAddr": "10.0.0.2"},
+	"handlers": [{"regexp": "/config", "handler": "config", "priority": 1}]
+}`
+
+func TestParseConfig(t *testing.T) {
+	expected := Config{
+		DeviceName:     "mac:112233445566",
+		FirmwareName:   "firmware",
+		DestinationURL: "https://talaria:6200/api/v2/device",
+		Queues:         QueuesConfig{Encoder: QueueConfig{MaxWorkers: 5, Overflow: OverflowDropOldest}},
+		Ping:           PingConfig{PingWait: 90 * time.Second},
+		Reconnect:      ReconnectConfig{Enabled: true, InitialInterval: 2 * time.Second, Jitter: 0.5},
+		TLS:            &TLSConfig{RootCAFiles: []string{"ca.pem"}},
+		Dialer:         DialerConfig{LocalAddr: "10.0.0.2"},
+		Handlers:       []RouteConfig{{Regexp: "/config", Handler: "config", Priority: 1}},
+	}
+	for name, data := range map[string]string{"yaml": yamlConfig, "json": jsonConfig} {
+		t.Run(name, func(t *testing.T) {
+			c, err := ParseConfig([]byte(data))
+			require.NoError(t, err)
+			assert.Equal(t, expected, c)
+			assert.NoError(t, c.Validate())
+		})
+	}
+
+	_, err := ParseConfig([]byte("deviceName: mac:112233445566\nnope: 1\n"))
+	assert.ErrorContains(t, err, "line 2: field nope not found")
+
+	c, err := ParseConfig(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Config{}, c)
+}
+
+func TestLoadConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "kratos.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(yamlConfig), 0600))
+	c, err := LoadConfig(file)
+	require.NoError(t, err)
+	assert.Equal(t, "mac:112233445566", c.DeviceName)
+
+	_, err = LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestConfigValidate(t *testing.T) {
+	c := Config{
+		DestinationURL: "ftp://talaria",
+		Queues:         QueuesConfig{Registry: QueueConfig{Size: -1, Overflow: "drop-everything", Timeout: -1}},
+		Reconnect:      ReconnectConfig{Multiplier: 0.5, Jitter: 2},
+		TLS:            &TLSConfig{CertificateFile: "cert.pem"},
+		Dialer:         DialerConfig{ConnectTimeout: -1, LocalAddr: "localhost"},
+		Redirect:       RedirectPolicy{StatusCodes: []int{307, 200}},
+		HandlerOrder:   "random",
+		Handlers:       []RouteConfig{{Regexp: "(", Handler: "", Timeout: -1, Types: []string{"Retrieve", "Bogus"}}},
+	}
+	err := c.Validate()
+	require.Error(t, err)
+
+	var fields []string
+	var list errorList
+	require.True(t, errors.As(err, &list))
+	for _, e := range list {
+		var configErr *ConfigError
+		require.True(t, errors.As(e, &configErr))
+		fields = append(fields, configErr.Field)
+	}
+	assert.Equal(t, []string{
+		"deviceName",
+		"destinationURL",
+		"queues.registry.size",
+		"queues.registry.overflow",
+		"queues.registry.timeout",
+		"reconnect.multiplier",
+		"reconnect.jitter",
+		"tls.keyFile",
+		"dialer.connectTimeout",
+		"dialer.localAddr",
+		"redirect.statusCodes[1]",
+		"handlerOrder",
+		"handlers[0].regexp",
+		"handlers[0].handler",
+		"handlers[0].timeout",
+		"handlers[0].types",
+	}, fields)
+	assert.ErrorIs(t, err, errRequired)
+	assert.ErrorIs(t, err, errIncompleteKeyPair)
+}
+
+func TestConfigApplyEnv(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	t.Setenv("KRATOS_DEVICE_NAME", "mac:665544332211")
+	t.Setenv("KRATOS_QUEUES_ENCODER_SIZE", "20")
+	t.Setenv("KRATOS_QUEUES_DECODER_OVERFLOW", "fail-fast")
+	t.Setenv("KRATOS_PING_PING_WAIT", "1m")
+	t.Setenv("KRATOS_RECONNECT_MULTIPLIER", "1.5")
+	t.Setenv("KRATOS_TLS_ROOT_CA_FILES", "a.pem, b.pem")
+	t.Setenv("KRATOS_TLS_MIN_VERSION", "772")
+	t.Setenv("KRATOS_BOOT_TIME", "2025-01-02T03:04:05Z")
+
+	c, err := ParseConfig([]byte(yamlConfig))
+	require.NoError(err)
+	require.NoError(c.ApplyEnv(DefaultEnvPrefix))
+	assert.Equal("mac:665544332211", c.DeviceName)
+	assert.Equal("firmware", c.FirmwareName)
+	assert.Equal(QueueConfig{MaxWorkers: 5, Size: 20, Overflow: OverflowDropOldest}, c.Queues.Encoder)
+	assert.Equal(OverflowFailFast, c.Queues.Decoder.Overflow)
+	assert.Equal(time.Minute, c.Ping.PingWait)
+	assert.Equal(1.5, c.Reconnect.Multiplier)
+	assert.Equal([]string{"a.pem", "b.pem"}, c.TLS.RootCAFiles)
+	assert.Equal(uint16(772), c.TLS.MinVersion)
+	assert.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), c.BootTime)
+
+	// pointers are only created when one of their fields is set.
+	var empty Config
+	require.NoError(empty.ApplyEnv("NOTHING"))
+	assert.Nil(empty.TLS)
+
+	t.Setenv("KRATOS_BOOT_TIME", "yesterday")
+	err = c.ApplyEnv(DefaultEnvPrefix)
+	assert.ErrorContains(err, "KRATOS_BOOT_TIME")
+
+	t.Setenv("KRATOS_BOOT_TIME", "2025-01-02T03:04:05Z")
+	t.Setenv("KRATOS_RECONNECT_ENABLED", "maybe")
+	err = c.ApplyEnv(DefaultEnvPrefix)
+	var configErr *ConfigError
+	require.True(errors.As(err, &configErr))
+	assert.Equal("KRATOS_RECONNECT_ENABLED", configErr.Field)
+}
+
+func TestEnvName(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("DEVICE_NAME", envName("deviceName"))
+	assert.Equal("DESTINATION_URL", envName("destinationURL"))
+	assert.Equal("ROOT_CA_FILES", envName("rootCAFiles"))
+	assert.Equal("TLS", envName("tls"))
+}
+
+func TestConfigClientConfig(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	c, err := ParseConfig([]byte(yamlConfig + `
+interfaceUsed: erouter0
+convey:
+  hw-model: TG1682
+headers:
+  x-custom: value
+`))
+	require.NoError(err)
+
+	handler := &myReadHandler{}
+	config, err := c.ClientConfig(map[string]DownstreamHandler{"config": handler})
+	require.NoError(err)
+	assert.Equal("mac:112233445566", config.DeviceName)
+	assert.Equal(c.Queues.Encoder, config.WRPEncoderQueue)
+	assert.Equal(c.Reconnect, config.Reconnect)
+	assert.Equal(c.Dialer, config.DialerConfig)
+	assert.Equal("erouter0", config.InterfaceUsed)
+	assert.Equal(map[string]any{"hw-model": "TG1682"}, config.Convey)
+	assert.Equal(http.Header{"X-Custom": {"value"}}, config.Headers)
+	assert.Equal([]HandlerConfig{{Regexp: "/config", Handler: handler, Priority: 1}}, config.Handlers)
+
+	_, err = c.ClientConfig(nil)
+	var configErr *ConfigError
+	require.True(errors.As(err, &configErr))
+	assert.Equal("handlers[0].handler", configErr.Field)
+	assert.ErrorIs(err, errUnboundHandler)
+
+	c.DeviceName = ""
+	_, err = c.ClientConfig(map[string]DownstreamHandler{"config": handler})
+	assert.ErrorIs(err, errRequired)
+}
+
+func TestConfigRouteMatchers(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	c, err := ParseConfig([]byte(`
+deviceName: mac:112233445566
+destinationURL: https://talaria:6200/api/v2/device
+handlers:
+  - name: events
+    types: [SimpleEvent]
+    handler: events
+  - name: config
+    types: [Retrieve, Update]
+    source:
+      scheme: dns
+    destination:
+      service: config
+    handler: config
+`))
+	require.NoError(err)
+
+	events, config := &echoHandler{}, &echoHandler{}
+	clientConfig, err := c.ClientConfig(map[string]DownstreamHandler{"events": events, "config": config})
+	require.NoError(err)
+	registry, err := NewHandlerRegistry(clientConfig.Handlers)
+	require.NoError(err)
+
+	handler, err := registry.HandlerFor(&wrp.Message{Type: wrp.SimpleEventMessageType, Destination: "event:device-status"})
+	require.NoError(err)
+	assert.Same(events, handler)
+
+	handler, err = registry.HandlerFor(&wrp.Message{
+		Type:        wrp.RetrieveMessageType,
+		Source:      "dns:talaria",
+		Destination: "mac:112233445566/config",
+	})
+	require.NoError(err)
+	assert.Same(config, handler)
+
+	_, err = registry.HandlerFor(&wrp.Message{
+		Type:        wrp.RetrieveMessageType,
+		Source:      "mac:665544332211",
+		Destination: "mac:112233445566/config",
+	})
+	assert.Error(err)
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	errNoConnection = errors.New("no websocket connection available")
+)
+
+// managedConnection is a websocketConnection whose underlying connection can
+// be replaced when the client reconnects.  The queues hold on to the
+// managedConnection, so they always write to the current connection.
+type managedConnection struct {
+	lock   sync.RWMutex
+	conn   websocketConnection
+	closed bool
+}
+
+// newManagedConnection wraps the given connection.
+func newManagedConnection(conn websocketConnection) *managedConnection {
+	return &managedConnection{conn: conn}
+}
+
+// current returns the connection currently in use.
+func (m *managedConnection) current() websocketConnection {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.conn
+}
+
+// WriteMessage writes to the current connection.
+func (m *managedConnection) WriteMessage(messageType int, data []byte) error {
+	conn := m.current()
+	if conn == nil {
+		return errNoConnection
+	}
+	return conn.WriteMessage(messageType, data)
+}
+
+// SetWriteDeadline sets the write deadline of the current connection.
+func (m *managedConnection) SetWriteDeadline(t time.Time) error {
+	conn := m.current()
+	if conn == nil {
+		return errNoConnection
+	}
+	return conn.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline of the current connection.
+func (m *managedConnection) SetReadDeadline(t time.Time) error {
+	conn := m.current()
+	if conn == nil {
+		return errNoConnection
+	}
+	return conn.SetReadDeadline(t)
+}
+
+// ReadMessage reads from the current connection.
+func (m *managedConnection) ReadMessage() (int, []byte, error) {
+	conn := m.current()
+	if conn == nil {
+		return 0, nil, errNoConnection
+	}
+	return conn.ReadMessage()
+}
+
+// Close closes the current connection.  Any connection set afterwards is
+// closed immediately.
+func (m *managedConnection) Close() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.closed = true
+	if m.conn == nil {
+		return nil
+	}
+	return m.conn.Close()
+}
+
+// set replaces the current connection, closing the old one.  If the
+// managedConnection has already been closed, the new connection is closed
+// and an error is returned.
+func (m *managedConnection) set(conn websocketConnection) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.closed {
+		conn.Close()
+		return ErrClientClosed
+	}
+	if m.conn != nil {
+		m.conn.Close()
+	}
+	m.conn = conn
+	return nil
+}
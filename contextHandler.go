@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+// ContextHandler is a handler that is given a context and can fail.  The
+// context is cancelled when the handler's timeout passes or once the client
+// has finished closing, and carries the Connection the message was received
+// on and the message's Trace.  Messages already received when the client is closed are still handled
+// before the context is cancelled.
+//
+// When an error is returned, an error response is sent to the message's
+// source instead of the response, if the message has a transaction.  Its status code comes from the error if
+// it implements StatusCoder, is 504 if the context's deadline was exceeded,
+// and is 500 otherwise.
+//
+// Use AdaptContextHandler to register a ContextHandler.
+type ContextHandler interface {
+	HandleMessageContext(ctx context.Context, msg *wrp.Message) (*wrp.Message, error)
+	Close()
+}
+
+// AdaptContextHandler makes a ContextHandler a DownstreamHandler, so it can
+// be registered.  The client passes its context to the ContextHandler.  When
+// HandleMessage is called directly, a background context is used and errors
+// are turned into error responses.
+func AdaptContextHandler(handler ContextHandler) DownstreamHandler {
+	return contextAdapter{handler}
+}
+
+// contextAdapter is a ContextHandler that is also a DownstreamHandler.
+type contextAdapter struct {
+	ContextHandler
+}
+
+func (c contextAdapter) HandleMessage(msg *wrp.Message) *wrp.Message {
+	return respond(msg)(c.HandleMessageContext(context.Background(), msg))
+}
+
+// handleContext has the handler handle the message with the context given,
+// if the handler takes a context.  Otherwise, the context is ignored.
+func handleContext(ctx context.Context, handler DownstreamHandler, msg *wrp.Message) (*wrp.Message, error) {
+	if h, ok := handler.(ContextHandler); ok {
+		return h.HandleMessageContext(ctx, msg)
+	}
+	return handler.HandleMessage(msg), nil
+}
+
+// respond gives the response to a message, which is an error response if
+// handling the message failed.  Messages without a transaction, such as
+// events, get no error response.
+func respond(msg *wrp.Message) func(*wrp.Message, error) *wrp.Message {
+	return func(response *wrp.Message, err error) *wrp.Message {
+		if err == nil {
+			return response
+		}
+		if msg.TransactionUUID == "" {
+			return nil
+		}
+		return errorResponse(msg, errorStatus(err), err)
+	}
+}
+
+// errorStatus is the status code of the error response for a handler's
+// error.
+func errorStatus(err error) int64 {
+	var coder StatusCoder
+	switch {
+	case errors.As(err, &coder):
+		return int64(coder.StatusCode())
+	case errors.Is(err, ErrHandlerTimeout), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Connection describes the connection a message was received on.
+type Connection struct {
+	DeviceID      string
+	URL           string
+	Hostname      string
+	RemoteAddr    net.Addr
+	RedirectChain []string
+}
+
+// connectionKey is the context key for the Connection.
+type connectionKey struct{}
+
+// withConnection adds the Connection to the context.
+func withConnection(ctx context.Context, connection Connection) context.Context {
+	return context.WithValue(ctx, connectionKey{}, connection)
+}
+
+// ConnectionFromContext gives the Connection a ContextHandler's message was
+// received on.
+func ConnectionFromContext(ctx context.Context) (Connection, bool) {
+	connection, ok := ctx.Value(connectionKey{}).(Connection)
+	return connection, ok
+}
+
+// Trace identifies a message for tracing.  TraceParent and TraceState are the
+// W3C trace context from the message's traceparent and tracestate headers, if
+// it has them.
+type Trace struct {
+	TransactionUUID string
+	SessionID       string
+	TraceParent     string
+	TraceState      string
+}
+
+// newTrace pulls the Trace out of the message.  Headers are "name: value"
+// pairs, and their names are matched without regard to case.
+func newTrace(msg *wrp.Message) Trace {
+	t := Trace{
+		TransactionUUID: msg.TransactionUUID,
+		SessionID:       msg.SessionID,
+	}
+	for _, header := range msg.Headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "traceparent":
+			t.TraceParent = strings.TrimSpace(value)
+		case "tracestate":
+			t.TraceState = strings.TrimSpace(value)
+		}
+	}
+	return t
+}
+
+// traceKey is the context key for the Trace.
+type traceKey struct{}
+
+// withTrace adds the Trace to the context.
+func withTrace(ctx context.Context, trace Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+// TraceFromContext gives the Trace of the message a ContextHandler is
+// handling.
+func TraceFromContext(ctx context.Context) (Trace, bool) {
+	trace, ok := ctx.Value(traceKey{}).(Trace)
+	return trace, ok
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v3"
+	"go.uber.org/zap"
+)
+
+func TestAdaptContextHandler(t *testing.T) {
+	tests := []struct {
+		description string
+		err         error
+		expected    int64
+	}{
+		{
+			description: "StatusCoder",
+			err:         NewStatusError(http.StatusNotFound, errors.New("no such parameter")),
+			expected:    http.StatusNotFound,
+		},
+		{
+			description: "Deadline",
+			err:         context.DeadlineExceeded,
+			expected:    http.StatusGatewayTimeout,
+		},
+		{
+			description: "Other",
+			err:         errors.New("failed"),
+			expected:    http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			handler := AdaptContextHandler(ContextHandlerFunc(func(context.Context, *wrp.Message) (*wrp.Message, error) {
+				return nil, tc.err
+			}))
+			response := handler.HandleMessage(&wrp.Message{
+				Source:          "dns:talaria",
+				Destination:     "mac:ffffff112233/config",
+				TransactionUUID: "123",
+			})
+			assert.Equal(tc.expected, *response.Status)
+			assert.Equal("dns:talaria", response.Destination)
+			assert.Equal("mac:ffffff112233/config", response.Source)
+			assert.Contains(string(response.Payload), tc.err.Error())
+		})
+	}
+
+	msg := &wrp.Message{}
+	handler := AdaptContextHandler(ContextHandlerFunc(func(_ context.Context, msg *wrp.Message) (*wrp.Message, error) {
+		return msg, nil
+	}))
+	assert.Same(t, msg, handler.HandleMessage(msg))
+}
+
+func TestDownstreamSenderContext(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	responses := make(chan *wrp.Message, 10)
+	sender := NewDownstreamSender(func(msg *wrp.Message) { responses <- msg },
+		QueueConfig{MaxWorkers: 2}, nil, zap.NewNop())
+	sender.connection = func() Connection {
+		return Connection{DeviceID: "mac:ffffff112233", URL: "ws://talaria/api/v2/device"}
+	}
+
+	// the context carries the connection and trace, and the middleware passes
+	// them on.
+	connections := make(chan Connection, 1)
+	traces := make(chan Trace, 1)
+	handler := Chain(LogMessages(zap.NewNop()))(AdaptContextHandler(ContextHandlerFunc(func(ctx context.Context, msg *wrp.Message) (*wrp.Message, error) {
+		connection, _ := ConnectionFromContext(ctx)
+		connections <- connection
+		trace, _ := TraceFromContext(ctx)
+		traces <- trace
+		return msg, nil
+	})))
+	msg := &wrp.Message{
+		TransactionUUID: "123",
+		SessionID:       "session",
+		Headers:         []string{"X-Other: 1", "Traceparent: 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "tracestate:congo=t61rcWkgMzE"},
+	}
+	sender.Send(handler, msg)
+	assert.Same(msg, <-responses)
+	assert.Equal("mac:ffffff112233", (<-connections).DeviceID)
+	assert.Equal(Trace{
+		TransactionUUID: "123",
+		SessionID:       "session",
+		TraceParent:     "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		TraceState:      "congo=t61rcWkgMzE",
+	}, <-traces)
+
+	// messages received before the sender is closed are handled with a live
+	// context, even those still queued, and the context is cancelled after.
+	release := make(chan struct{})
+	contexts := make(chan context.Context, 3)
+	blocking := AdaptContextHandler(ContextHandlerFunc(func(ctx context.Context, msg *wrp.Message) (*wrp.Message, error) {
+		<-release
+		contexts <- ctx
+		return msg, ctx.Err()
+	}))
+	first, second, queued := &wrp.Message{}, &wrp.Message{}, &wrp.Message{}
+	sender.Send(blocking, first)
+	sender.Send(blocking, second)
+	sender.Send(&timeoutHandler{DownstreamHandler: blocking, timeout: time.Minute}, queued)
+	closed := make(chan struct{})
+	go func() {
+		sender.Close()
+		close(closed)
+	}()
+	assert.Eventually(func() bool { return sender.closed.Load() == true }, time.Second, time.Millisecond)
+	close(release)
+	<-closed
+	got := []*wrp.Message{<-responses, <-responses, <-responses}
+	assert.ElementsMatch([]*wrp.Message{first, second, queued}, got)
+	ctx := <-contexts
+	require.Error(ctx.Err())
+	assert.ErrorIs(ctx.Err(), context.Canceled)
+}
+
+func TestDownstreamSenderContextTimeout(t *testing.T) {
+	responses := make(chan *wrp.Message, 10)
+	sender := NewDownstreamSender(func(msg *wrp.Message) { responses <- msg },
+		QueueConfig{Timeout: 20 * time.Millisecond}, nil, zap.NewNop())
+	defer sender.Close()
+
+	deadlines := make(chan error, 1)
+	sender.Send(AdaptContextHandler(ContextHandlerFunc(func(ctx context.Context, _ *wrp.Message) (*wrp.Message, error) {
+		<-ctx.Done()
+		deadlines <- ctx.Err()
+		return nil, ctx.Err()
+	})), &wrp.Message{TransactionUUID: "123"})
+	assert.Equal(t, int64(http.StatusGatewayTimeout), *(<-responses).Status)
+	assert.ErrorIs(t, <-deadlines, context.DeadlineExceeded)
+}
@@ -29,7 +29,10 @@ type decoderSender interface {
 type decoderQueue struct {
 	incoming chan []byte
 	sender   registryHandler
+	policy   OverflowPolicy
+	metrics  *Metrics
 	workers  *semaphore.Weighted
+	codecs   *codecs
 	wg       sync.WaitGroup
 	logger   *zap.Logger
 	once     sync.Once
@@ -38,19 +41,18 @@ type decoderQueue struct {
 
 // NewDecoderSender creates a new decoderQueue for decoding and sending
 // messages.
-func NewDecoderSender(sender registryHandler, maxWorkers int, queueSize int, logger *zap.Logger) *decoderQueue {
-	size := queueSize
+func NewDecoderSender(sender registryHandler, config QueueConfig, metrics *Metrics, logger *zap.Logger) *decoderQueue {
+	size := config.Size
 	if size < minQueueSize {
 		size = minQueueSize
 	}
-	numWorkers := maxWorkers
-	if numWorkers < minWorkers {
-		numWorkers = minWorkers
-	}
 	d := decoderQueue{
 		incoming: make(chan []byte, size),
 		sender:   sender,
-		workers:  semaphore.NewWeighted(int64(numWorkers)),
+		policy:   config.Overflow,
+		metrics:  metrics,
+		workers:  config.newWorkers(),
+		codecs:   config.newCodecs(),
 		logger:   logger,
 	}
 	d.wg.Add(1)
@@ -58,15 +60,22 @@ func NewDecoderSender(sender registryHandler, maxWorkers int, queueSize int, log
 	return &d
 }
 
-// DecodeAndSend places the message on the queue.  This will block when the
-// queue is full.  This should not be called after Close().
+// DecodeAndSend places the message on the queue.  When the queue is full, the
+// queue's OverflowPolicy is followed.  This should not be called after Close().
 func (d *decoderQueue) DecodeAndSend(msg []byte) {
 	switch d.closed.Load() {
 	case true:
 		d.logger.Error(
 			"Failed to queue message. DecoderQueue is no longer accepting messages.")
 	default:
-		d.incoming <- msg
+		err := enqueue(context.Background(), d.incoming, msg, d.policy, func([]byte) {
+			d.logger.Warn("Dropped oldest message from DecoderQueue.")
+			d.metrics.droppedOldest(StageDecoder)
+		})
+		d.metrics.enqueueResult(StageDecoder, err)
+		if err != nil {
+			d.logger.Error("Failed to queue message.", zap.Error(err))
+		}
 	}
 }
 
@@ -88,6 +97,7 @@ func (d *decoderQueue) startParsing() {
 	ctx := context.Background()
 	defer d.wg.Done()
 	for i := range d.incoming {
+		d.metrics.dequeued(StageDecoder)
 		d.workers.Acquire(ctx, 1)
 		d.wg.Add(1)
 		go d.parse(i)
@@ -99,16 +109,20 @@ func (d *decoderQueue) startParsing() {
 func (d *decoderQueue) parse(incoming []byte) {
 	defer d.wg.Done()
 	defer d.workers.Release(1)
+	d.metrics.workerStarted(StageDecoder)
+	defer d.metrics.workerDone(StageDecoder)
 	msg := wrp.Message{}
 
 	// decoding
 	d.logger.Debug("Decoding message...")
-	err := wrp.NewDecoderBytes(incoming, wrp.Msgpack).Decode(&msg)
+	err := d.codecs.decode(incoming, &msg)
 	if err != nil {
 		d.logger.Error("Failed to decode message into wrp", zap.Error(err))
+		d.metrics.decodeFailure()
 		return
 	}
 	d.logger.Debug("Message Decoded")
+	d.metrics.message(inboundDirection, msg.Type)
 
 	// sending
 	d.sender.GetHandlerThenSend(&msg)
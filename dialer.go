@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// DefaultHandshakeTimeout is the time allowed for the websocket handshake
+	// when DialerConfig.HandshakeTimeout is not set.
+	DefaultHandshakeTimeout = 45 * time.Second
+
+	// DefaultConnectTimeout is the time allowed to open the network
+	// connection when DialerConfig.ConnectTimeout is not set.
+	DefaultConnectTimeout = 30 * time.Second
+)
+
+var (
+	errInvalidLocalAddr    = errors.New("local address must be an IP address, optionally with a port")
+	errUnixLocalAddr       = errors.New("a local address can't be used with a unix socket")
+	errTLSWithCustomDialer = errors.New("TLS can't be applied to a custom Dialer; configure TLS on the Dialer instead")
+)
+
+// Dialer opens the websocket connection to XMiDT.  *websocket.Dialer is a
+// Dialer.
+type Dialer interface {
+	DialContext(ctx context.Context, urlStr string, requestHeader http.Header) (*websocket.Conn, *http.Response, error)
+}
+
+// DialerConfig configures the dialer a client makes when it isn't given a
+// Dialer.
+type DialerConfig struct {
+	// HandshakeTimeout is the time allowed for the websocket handshake.
+	// Defaults to DefaultHandshakeTimeout.
+	HandshakeTimeout time.Duration `yaml:"handshakeTimeout"`
+
+	// ConnectTimeout is the time allowed to open the network connection.
+	// Defaults to DefaultConnectTimeout.
+	ConnectTimeout time.Duration `yaml:"connectTimeout"`
+
+	// KeepAlive is the interval between TCP keep-alive probes.  Zero uses the
+	// system default, and a negative value disables them.
+	KeepAlive time.Duration `yaml:"keepAlive"`
+
+	// Proxy is the URL of the HTTP proxy to connect through.  When empty,
+	// the proxy is taken from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables.
+	Proxy string `yaml:"proxy"`
+
+	// LocalAddr is the IP address, optionally with a port, that connections
+	// are made from.  This lets emulated devices connect from different IP
+	// aliases of the same host.
+	LocalAddr string `yaml:"localAddr"`
+
+	// UnixSocket is the path of a unix socket to connect to instead of the
+	// host in the URL, such as a local test server.  No proxy is used.
+	UnixSocket string `yaml:"unixSocket"`
+
+	// ReadBufferSize and WriteBufferSize are the sizes of the websocket's I/O
+	// buffers.  Zero uses the websocket package's defaults.
+	ReadBufferSize  int `yaml:"readBufferSize"`
+	WriteBufferSize int `yaml:"writeBufferSize"`
+}
+
+// validate checks the DialerConfig, without building a dialer.
+func (d DialerConfig) validate() error {
+	_, err := d.NewDialer(nil)
+	return err
+}
+
+// NewDialer builds the *websocket.Dialer described by the DialerConfig, using
+// the TLS configuration given for wss connections.
+func (d DialerConfig) NewDialer(tlsConfig *tls.Config) (*websocket.Dialer, error) {
+	for _, value := range []time.Duration{d.HandshakeTimeout, d.ConnectTimeout} {
+		if err := notNegative(value); err != nil {
+			return nil, err
+		}
+	}
+	if err := notNegative(d.ReadBufferSize); err != nil {
+		return nil, err
+	}
+	if err := notNegative(d.WriteBufferSize); err != nil {
+		return nil, err
+	}
+
+	netDialer := &net.Dialer{
+		Timeout:   d.ConnectTimeout,
+		KeepAlive: d.KeepAlive,
+	}
+	if netDialer.Timeout == 0 {
+		netDialer.Timeout = DefaultConnectTimeout
+	}
+	if d.LocalAddr != "" {
+		if d.UnixSocket != "" {
+			return nil, errUnixLocalAddr
+		}
+		addr, err := parseLocalAddr(d.LocalAddr)
+		if err != nil {
+			return nil, err
+		}
+		netDialer.LocalAddr = addr
+	}
+
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: d.HandshakeTimeout,
+		ReadBufferSize:   d.ReadBufferSize,
+		WriteBufferSize:  d.WriteBufferSize,
+		TLSClientConfig:  tlsConfig,
+		NetDialContext:   netDialer.DialContext,
+	}
+	if dialer.HandshakeTimeout == 0 {
+		dialer.HandshakeTimeout = DefaultHandshakeTimeout
+	}
+	if d.Proxy != "" {
+		proxyURL, err := url.Parse(d.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy: %w", err)
+		}
+		dialer.Proxy = http.ProxyURL(proxyURL)
+	}
+	if d.UnixSocket != "" {
+		path := d.UnixSocket
+		dialer.Proxy = nil
+		dialer.NetDialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return netDialer.DialContext(ctx, "unix", path)
+		}
+	}
+	return dialer, nil
+}
+
+// parseLocalAddr parses an IP address, optionally with a port.
+func parseLocalAddr(addr string) (*net.TCPAddr, error) {
+	if ip := net.ParseIP(addr); ip != nil {
+		return &net.TCPAddr{IP: ip}, nil
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w [%v]", errInvalidLocalAddr, addr)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, fmt.Errorf("%w [%v]", errInvalidLocalAddr, addr)
+	}
+	p, err := net.LookupPort("tcp", port)
+	if err != nil {
+		return nil, fmt.Errorf("%w [%v]", errInvalidLocalAddr, addr)
+	}
+	return &net.TCPAddr{IP: ip, Port: p}, nil
+}
+
+// newDialer chooses the Dialer a client connects with.  A *websocket.Dialer is
+// copied so the TLS configuration can be applied to it, any other Dialer is
+// used as it is, and without a Dialer one is built from the DialerConfig.
+func newDialer(dialer Dialer, config DialerConfig, tlsConfig *tls.Config) (Dialer, error) {
+	switch d := dialer.(type) {
+	case nil:
+		d, err := config.NewDialer(tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	case *websocket.Dialer:
+		copied := *d
+		if tlsConfig != nil {
+			copied.TLSClientConfig = tlsConfig
+		}
+		return &copied, nil
+	default:
+		if tlsConfig != nil {
+			return nil, errTLSWithCustomDialer
+		}
+		return dialer, nil
+	}
+}
@@ -0,0 +1,180 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingDialer counts the connections made through it.
+type countingDialer struct {
+	dials atomic.Int32
+}
+
+func (d *countingDialer) DialContext(ctx context.Context, urlStr string, header http.Header) (*websocket.Conn, *http.Response, error) {
+	d.dials.Add(1)
+	return websocket.DefaultDialer.DialContext(ctx, urlStr, header)
+}
+
+func TestDialerConfigUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "talaria.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader.Upgrade(w, r, nil)
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	config := clientConfig
+	config.DestinationURL = "http://talaria/api/v2/device"
+	config.DialerConfig = DialerConfig{UnixSocket: socket}
+	testClient, err := NewClient(config)
+	require.NoError(t, err)
+	assert.Equal(t, "talaria", testClient.Hostname())
+	assert.NoError(t, testClient.Close())
+}
+
+func TestDialerConfigLocalAddr(t *testing.T) {
+	remoteAddrs := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteAddrs <- r.RemoteAddr
+		upgrader.Upgrade(w, r, nil)
+	}))
+	defer server.Close()
+
+	config := clientConfig
+	config.DestinationURL = server.URL
+	config.DialerConfig = DialerConfig{LocalAddr: "127.0.0.1"}
+	testClient, err := NewClient(config)
+	require.NoError(t, err)
+	defer testClient.Close()
+
+	host, _, err := net.SplitHostPort(<-remoteAddrs)
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", host)
+}
+
+func TestDialerConfigProxy(t *testing.T) {
+	// tunnel CONNECT requests, counting them.
+	var tunnels atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		tunnels.Add(1)
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		go func() {
+			io.Copy(upstream, conn)
+			upstream.Close()
+		}()
+		io.Copy(conn, upstream)
+		conn.Close()
+	}))
+	defer proxy.Close()
+
+	config := clientConfig
+	config.DialerConfig = DialerConfig{Proxy: proxy.URL}
+	testClient, err := NewClient(config)
+	require.NoError(t, err)
+	defer testClient.Close()
+	assert.Equal(t, int32(1), tunnels.Load())
+}
+
+func TestDialerConfigErrors(t *testing.T) {
+	tests := []struct {
+		description string
+		config      DialerConfig
+		expectedErr error
+	}{
+		{
+			description: "negative timeout",
+			config:      DialerConfig{HandshakeTimeout: -1},
+			expectedErr: errNegative,
+		},
+		{
+			description: "negative buffer",
+			config:      DialerConfig{WriteBufferSize: -1},
+			expectedErr: errNegative,
+		},
+		{
+			description: "hostname local address",
+			config:      DialerConfig{LocalAddr: "localhost:80"},
+			expectedErr: errInvalidLocalAddr,
+		},
+		{
+			description: "bad port",
+			config:      DialerConfig{LocalAddr: "10.0.0.1:http-ish"},
+			expectedErr: errInvalidLocalAddr,
+		},
+		{
+			description: "unix socket with a local address",
+			config:      DialerConfig{LocalAddr: "10.0.0.1", UnixSocket: "/tmp/talaria.sock"},
+			expectedErr: errUnixLocalAddr,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := tc.config.NewDialer(nil)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+
+	_, err := DialerConfig{Proxy: "://proxy"}.NewDialer(nil)
+	assert.Error(t, err)
+}
+
+func TestNewDialer(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	custom := &countingDialer{}
+	config := clientConfig
+	config.Dialer = custom
+	testClient, err := NewClient(config)
+	require.NoError(err)
+	require.NoError(testClient.Close())
+	assert.Equal(int32(1), custom.dials.Load())
+
+	config.TLS = &TLSConfig{}
+	_, err = NewClient(config)
+	assert.ErrorIs(err, errTLSWithCustomDialer)
+
+	// a *websocket.Dialer is copied before the TLS is applied to it.
+	shared := &websocket.Dialer{}
+	tlsConfig := &tls.Config{} //nolint:gosec
+	dialer, err := newDialer(shared, DialerConfig{}, tlsConfig)
+	require.NoError(err)
+	assert.NotSame(shared, dialer)
+	assert.Nil(shared.TLSClientConfig)
+	assert.Same(tlsConfig, dialer.(*websocket.Dialer).TLSClientConfig)
+
+	dialer, err = newDialer(nil, DialerConfig{}, nil)
+	require.NoError(err)
+	assert.Equal(DefaultHandshakeTimeout, dialer.(*websocket.Dialer).HandshakeTimeout)
+}
@@ -4,8 +4,8 @@
 package kratos
 
 import (
-	"bytes"
 	"context"
+	"fmt"
 	"sync"
 	"sync/atomic"
 
@@ -17,14 +17,32 @@ import (
 // encoderSender is anything that can encode and send a message.
 type encoderSender interface {
 	EncodeAndSend(*wrp.Message)
+	EncodeAndSendContext(context.Context, *wrp.Message) error
 	Close()
 }
 
+// encodeRequest is a message waiting to be encoded.  If result is set, the
+// outcome of encoding and queueing the message to be sent is reported on it.
+type encodeRequest struct {
+	msg    *wrp.Message
+	result chan error
+}
+
+// done reports the outcome of the request, if anyone is waiting on it.
+func (r encodeRequest) done(err error) {
+	if r.result != nil {
+		r.result <- err
+	}
+}
+
 // encoderQueue implements an asynchronous encoderSender.
 type encoderQueue struct {
-	incoming chan *wrp.Message
+	incoming chan encodeRequest
 	sender   outboundSender
+	policy   OverflowPolicy
+	metrics  *Metrics
 	workers  *semaphore.Weighted
+	codecs   *codecs
 	wg       sync.WaitGroup
 	logger   *zap.Logger
 	once     sync.Once
@@ -33,19 +51,18 @@ type encoderQueue struct {
 
 // NewEncoderSender creates a new encoderQueue, that allows for asynchronous
 // sending outbound.
-func NewEncoderSender(sender outboundSender, maxWorkers int, queueSize int, logger *zap.Logger) *encoderQueue {
-	size := queueSize
+func NewEncoderSender(sender outboundSender, config QueueConfig, metrics *Metrics, logger *zap.Logger) *encoderQueue {
+	size := config.Size
 	if size < minQueueSize {
 		size = minQueueSize
 	}
-	numWorkers := maxWorkers
-	if numWorkers < minWorkers {
-		numWorkers = minWorkers
-	}
 	e := encoderQueue{
-		incoming: make(chan *wrp.Message, size),
+		incoming: make(chan encodeRequest, size),
 		sender:   sender,
-		workers:  semaphore.NewWeighted(int64(numWorkers)),
+		policy:   config.Overflow,
+		metrics:  metrics,
+		workers:  config.newWorkers(),
+		codecs:   config.newCodecs(),
 		logger:   logger,
 	}
 	e.wg.Add(1)
@@ -53,17 +70,42 @@ func NewEncoderSender(sender outboundSender, maxWorkers int, queueSize int, logg
 	return &e
 }
 
-// EncodeAndSend adds the message to the queue to be sent.  It will block if
-// the queue is full.  This should not be called after Close().
-// TODO: we should consider returning an error in the case in which we can no longer encode
+// EncodeAndSend adds the message to the queue to be sent.  When the queue is
+// full, the queue's OverflowPolicy is followed.  Any failure is logged.
 func (e *encoderQueue) EncodeAndSend(msg *wrp.Message) {
-	switch e.closed.Load() {
-	case true:
-		e.logger.Error(
-			"Failed to queue message. EncoderQueue is no longer accepting messages.")
-	default:
-		e.incoming <- msg
+	if err := e.enqueue(context.Background(), encodeRequest{msg: msg}); err != nil {
+		e.logger.Error("Failed to queue message.", zap.Error(err))
+	}
+}
+
+// EncodeAndSendContext adds the message to the queue to be sent, then waits
+// until the message has been encoded and handed off to be written.  When the
+// queue is full, the queue's OverflowPolicy is followed.
+func (e *encoderQueue) EncodeAndSendContext(ctx context.Context, msg *wrp.Message) error {
+	result := make(chan error, 1)
+	if err := e.enqueue(ctx, encodeRequest{msg: msg, result: result}); err != nil {
+		return err
 	}
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// enqueue places the request on the queue, unless the queue has been closed.
+func (e *encoderQueue) enqueue(ctx context.Context, request encodeRequest) error {
+	if e.closed.Load() == true {
+		return ErrClientClosed
+	}
+	err := enqueue(ctx, e.incoming, request, e.policy, func(dropped encodeRequest) {
+		e.logger.Warn("Dropped oldest message from EncoderQueue.")
+		e.metrics.droppedOldest(StageEncoder)
+		dropped.done(ErrMessageDropped)
+	})
+	e.metrics.enqueueResult(StageEncoder, err)
+	return err
 }
 
 // Close closes the queue, not allowing any more messages to be sent.  Then
@@ -85,6 +127,7 @@ func (e *encoderQueue) startParsing() {
 	ctx := context.Background() // TODO - does this need to be withCancel?
 
 	for i := range e.incoming {
+		e.metrics.dequeued(StageEncoder)
 		e.workers.Acquire(ctx, 1)
 		e.wg.Add(1)
 		go e.parse(i)
@@ -92,22 +135,34 @@ func (e *encoderQueue) startParsing() {
 }
 
 // parse encodes the wrp message and then uses the outboundSender to send it.
-func (e *encoderQueue) parse(incoming *wrp.Message) {
+func (e *encoderQueue) parse(incoming encodeRequest) {
 	defer e.wg.Done()
 	defer e.workers.Release(1)
-	var buffer bytes.Buffer
+	e.metrics.workerStarted(StageEncoder)
+	defer e.metrics.workerDone(StageEncoder)
 
 	// encoding
-	e.logger.Error("Encoding message...")
-	err := wrp.NewEncoder(&buffer, wrp.Msgpack).Encode(incoming)
+	e.logger.Debug("Encoding message...")
+	data, err := e.codecs.encode(incoming.msg)
 	if err != nil {
 		e.logger.Error("Failed to encode message", zap.Error(err),
-			zap.Any("message", incoming))
+			zap.Any("message", incoming.msg))
+		e.metrics.encodeFailure()
+		incoming.done(fmt.Errorf("%w: %w", ErrEncodeFailure, err))
 		return
 	}
 	e.logger.Debug("Message Encoded")
 
 	// sending
-	e.sender.Send(buffer.Bytes())
+	err = e.sender.Send(data)
+	if err != nil {
+		e.logger.Error("Failed to queue encoded message", zap.Error(err))
+		incoming.done(err)
+		return
+	}
+	incoming.done(nil)
+	if incoming.msg != nil {
+		e.metrics.message(outboundDirection, incoming.msg.Type)
+	}
 	e.logger.Debug("Message Sent")
 }
@@ -5,6 +5,7 @@ package kratos
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -18,6 +19,22 @@ const (
 	StatusDeviceTimeout      int = 524
 )
 
+var (
+	// ErrClientClosed is returned when a message is sent after the client
+	// has been closed.
+	ErrClientClosed = errors.New("client is closed")
+
+	// ErrQueueFull is returned when a queue using OverflowFailFast is full.
+	ErrQueueFull = errors.New("queue is full")
+
+	// ErrMessageDropped is returned when a message is discarded by a queue
+	// using OverflowDropNewest or OverflowDropOldest.
+	ErrMessageDropped = errors.New("message was dropped by a full queue")
+
+	// ErrEncodeFailure is returned when a message cannot be encoded.
+	ErrEncodeFailure = errors.New("failed to encode message")
+)
+
 type Message struct {
 	Code int    `json:"code"`
 	Body string `json:"body"`
@@ -33,6 +50,30 @@ type MessageBodyer interface {
 	MessageBody() string
 }
 
+// statusError is an error with a status code.
+type statusError struct {
+	code int
+	err  error
+}
+
+// NewStatusError gives the error a status code, such as for a ContextHandler
+// to choose the status of its error response.
+func NewStatusError(code int, err error) error {
+	return &statusError{code: code, err: err}
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+func (e *statusError) StatusCode() int {
+	return e.code
+}
+
 func (msg Message) String() string {
 	return fmt.Sprintf("%d:%s", msg.Code, msg.Body)
 }
@@ -49,6 +90,9 @@ func createHTTPError(resp *http.Response, err error) *httpError {
 	data, _ := io.ReadAll(resp.Body)
 	json.Unmarshal(data, &msg)
 
+	if msg.Code == 0 {
+		msg.Code = resp.StatusCode
+	}
 	if msg.Body == "" {
 		switch resp.StatusCode {
 		case StatusDeviceDisconnected:
@@ -93,6 +137,11 @@ func (es errorList) Errors() []error {
 	return es
 }
 
+// Unwrap lets errors.Is and errors.As look at every error in the list.
+func (es errorList) Unwrap() []error {
+	return es
+}
+
 func CreateErrorWRP(transaction string, dest string, src string, statusCode int64, err error) *wrp.Message {
 	response := wrp.Message{
 		Type:            wrp.SimpleRequestResponseMessageType,
@@ -16,22 +16,16 @@ var (
 	mainWG sync.WaitGroup
 )
 
-type myReadHandler struct {
-	helloMsg   string
-	goodbyeMsg string
-}
-
-func (m *myReadHandler) HandleMessage(msg *wrp.Message) *wrp.Message {
-	fmt.Println()
-	fmt.Println(m.helloMsg)
-	fmt.Println(m.goodbyeMsg)
-	fmt.Println(msg)
-	mainWG.Done()
-	return msg
-}
-
-func (m *myReadHandler) Close() {
-	fmt.Println("close")
+// readHandler prints the greeting and each message it handles.
+func readHandler(helloMsg, goodbyeMsg string) kratos.HandlerFunc {
+	return func(msg *wrp.Message) *wrp.Message {
+		fmt.Println()
+		fmt.Println(helloMsg)
+		fmt.Println(goodbyeMsg)
+		fmt.Println(msg)
+		mainWG.Done()
+		return msg
+	}
 }
 
 func main() {
@@ -45,25 +39,18 @@ func main() {
 		DestinationURL: "http://localhost:6200/api/v2/device",
 		Handlers: []kratos.HandlerConfig{
 			{
-				Regexp: "/foo",
-				Handler: &myReadHandler{
-					helloMsg:   "Hello.",
-					goodbyeMsg: "I am Kratos.",
-				},
+				Regexp:  "/foo",
+				Handler: readHandler("Hello.", "I am Kratos."),
 			},
 			{
-				Regexp: "/bar",
-				Handler: &myReadHandler{
-					helloMsg:   "Hi.",
-					goodbyeMsg: "My name is Kratos.",
-				},
+				Regexp:  "/bar",
+				Handler: readHandler("Hi.", "My name is Kratos."),
 			},
 			{
-				Regexp: ".*",
-				Handler: &myReadHandler{
-					helloMsg:   "Hey.",
-					goodbyeMsg: "Have you met Kratos?",
-				},
+				// the catch-all is matched last, after the more specific handlers.
+				Regexp:   ".*",
+				Priority: -1,
+				Handler:  readHandler("Hey.", "Have you met Kratos?"),
 			},
 		},
 		HandlePingMiss: func() error {
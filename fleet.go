@@ -0,0 +1,452 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xmidt-org/wrp-go/v3"
+	"golang.org/x/sync/semaphore"
+)
+
+const (
+	// Default number of workers shared by a fleet's queues of each stage.
+	defaultFleetWorkers = 16
+
+	// Largest MAC address a device in a fleet can have.
+	maxMAC = 1<<48 - 1
+)
+
+var (
+	errNoDevices          = errors.New("fleet must have at least one device")
+	errInvalidFirstDevice = errors.New("FirstDeviceID must be a mac device id")
+	errTooManyDevices     = errors.New("fleet goes past the last mac address")
+	errFleetStarted       = errors.New("fleet has already been started")
+	errFleetLocalAddrs    = errors.New("LocalAddrs can't be used with a Template Dialer")
+)
+
+// FleetConfig is the configuration to provide when making a new Fleet.
+type FleetConfig struct {
+	// Template is the configuration every device starts from.  DeviceName,
+	// FirmwareName and ModelName are set for each device.  The MaxWorkers of
+	// each queue is the size of the worker pool shared by every device's
+	// queue of that stage.
+	Template ClientConfig
+
+	// Devices is the number of devices in the fleet.
+	Devices int
+
+	// FirstDeviceID is the mac device id of the first device.  The rest of
+	// the devices count up from it.
+	FirstDeviceID string
+
+	// FirmwareNames and ModelNames are given to the devices in turn.  When
+	// empty, the Template's names are used.
+	FirmwareNames []string
+	ModelNames    []string
+
+	// LocalAddrs are given to the devices in turn as the LocalAddr of their
+	// DialerConfig, so that the fleet connects from many IP aliases of the
+	// same host.  They can't be used with a Template Dialer.
+	LocalAddrs []string
+
+	// NewHandlers creates the handlers for a device.  When nil, every device
+	// shares the Template's handlers, which then have Close called once per
+	// device.
+	NewHandlers func(deviceID wrp.DeviceID) []HandlerConfig
+
+	// ConnectInterval is the time between starting to connect each device.
+	ConnectInterval time.Duration
+
+	// MaxConcurrentConnects limits how many devices can be connecting at
+	// once.  The default is 1.
+	MaxConcurrentConnects int
+}
+
+// DeviceState is where a device in a Fleet is in its lifecycle.
+type DeviceState string
+
+const (
+	// DevicePending has not started connecting yet.
+	DevicePending DeviceState = "pending"
+
+	// DeviceConnecting is making its initial connection.
+	DeviceConnecting DeviceState = "connecting"
+
+	// DeviceConnected has a connection.
+	DeviceConnected DeviceState = "connected"
+
+	// DeviceDisconnected lost its connection, and may be reconnecting.
+	DeviceDisconnected DeviceState = "disconnected"
+
+	// DeviceFailed could not make its initial connection.
+	DeviceFailed DeviceState = "failed"
+
+	// DeviceClosed has been closed.
+	DeviceClosed DeviceState = "closed"
+)
+
+// DeviceStatus reports on one device in a Fleet.
+type DeviceStatus struct {
+	DeviceID wrp.DeviceID
+	State    DeviceState
+
+	// Hostname is the host the device is connected to, if it has connected.
+	Hostname string
+
+	// Err is the last error the device ran into, if any.
+	Err error
+
+	// Connects and Disconnects count how many times the device has connected
+	// and lost its connection.
+	Connects    int
+	Disconnects int
+
+	// Since is when the device entered its State.
+	Since time.Time
+}
+
+// Fleet runs many emulated devices in one process.  The devices share worker
+// pools and msgpack encoders and decoders, so thousands of devices don't need
+// thousands of idle workers.
+type Fleet struct {
+	config  FleetConfig
+	devices []*fleetDevice
+	byID    map[wrp.DeviceID]*fleetDevice
+	started bool
+	lock    sync.Mutex
+	done    chan struct{}
+	once    sync.Once
+}
+
+// fleetDevice is a device in a Fleet.  It listens to its client to keep its
+// status up to date.
+type fleetDevice struct {
+	config ClientConfig
+	client Client
+	status DeviceStatus
+	lock   sync.Mutex
+}
+
+// NewFleet creates a Fleet from a FleetConfig.  No devices connect until
+// Start is called.
+func NewFleet(config FleetConfig) (*Fleet, error) {
+	if config.Devices < 1 {
+		return nil, errNoDevices
+	}
+	if config.Template.HandlePingMiss == nil {
+		return nil, errNilHandlePingMiss
+	}
+	first, err := parseMAC(config.FirstDeviceID)
+	if err != nil {
+		return nil, err
+	}
+	if first+uint64(config.Devices)-1 > maxMAC {
+		return nil, errTooManyDevices
+	}
+	if len(config.LocalAddrs) > 0 && config.Template.Dialer != nil {
+		return nil, errFleetLocalAddrs
+	}
+	for _, addr := range config.LocalAddrs {
+		if _, err := parseLocalAddr(addr); err != nil {
+			return nil, err
+		}
+	}
+
+	// share a worker pool for each stage, and the encoders and decoders.
+	template := config.Template
+	for _, q := range []*QueueConfig{&template.WRPEncoderQueue, &template.WRPDecoderQueue, &template.HandlerRegistryQueue, &template.HandleMsgQueue} {
+		if q.MaxWorkers < 1 {
+			q.MaxWorkers = defaultFleetWorkers
+		}
+		q.workers = semaphore.NewWeighted(int64(q.MaxWorkers))
+	}
+	shared := newCodecs()
+	template.WRPEncoderQueue.codecs = shared
+	template.WRPDecoderQueue.codecs = shared
+
+	f := &Fleet{
+		config:  config,
+		devices: make([]*fleetDevice, config.Devices),
+		byID:    make(map[wrp.DeviceID]*fleetDevice, config.Devices),
+		done:    make(chan struct{}),
+	}
+	now := time.Now()
+	for i := range f.devices {
+		id := wrp.DeviceID(fmt.Sprintf("mac:%012x", first+uint64(i)))
+		d := &fleetDevice{
+			config: template,
+			status: DeviceStatus{DeviceID: id, State: DevicePending, Since: now},
+		}
+		d.config.DeviceName = string(id)
+		if n := len(config.FirmwareNames); n > 0 {
+			d.config.FirmwareName = config.FirmwareNames[i%n]
+		}
+		if n := len(config.ModelNames); n > 0 {
+			d.config.ModelName = config.ModelNames[i%n]
+		}
+		if n := len(config.LocalAddrs); n > 0 {
+			d.config.DialerConfig.LocalAddr = config.LocalAddrs[i%n]
+		}
+		if config.NewHandlers != nil {
+			d.config.Handlers = config.NewHandlers(id)
+		}
+		d.config.Listeners = append(append([]ClientListener{}, template.Listeners...), d)
+		f.devices[i] = d
+		f.byID[id] = d
+	}
+	return f, nil
+}
+
+// parseMAC gets the MAC address out of a mac device id.
+func parseMAC(deviceID string) (uint64, error) {
+	id, err := wrp.ParseDeviceID(deviceID)
+	if err != nil {
+		return 0, err
+	}
+	mac, found := strings.CutPrefix(string(id), "mac:")
+	if !found {
+		return 0, errInvalidFirstDevice
+	}
+	return strconv.ParseUint(mac, 16, 64)
+}
+
+// Start connects every device in the fleet, staggered by the
+// ConnectInterval.  It blocks until every device has tried to connect, the
+// context is done, or the fleet is closed, which also stops the devices that
+// are connecting.  Devices that fail to connect are reported by Status.
+func (f *Fleet) Start(ctx context.Context) error {
+	f.lock.Lock()
+	if f.started {
+		f.lock.Unlock()
+		return errFleetStarted
+	}
+	f.started = true
+	f.lock.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-f.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	maxConnects := f.config.MaxConcurrentConnects
+	if maxConnects < 1 {
+		maxConnects = 1
+	}
+	connecting := semaphore.NewWeighted(int64(maxConnects))
+
+	var (
+		wg  sync.WaitGroup
+		err error
+	)
+	for i, d := range f.devices {
+		if i > 0 && f.config.ConnectInterval > 0 {
+			timer := time.NewTimer(f.config.ConnectInterval)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+			case <-f.done:
+			}
+			timer.Stop()
+		}
+		if err = f.stopped(ctx); err != nil {
+			break
+		}
+		if err = connecting.Acquire(ctx, 1); err != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer connecting.Release(1)
+			f.connect(ctx, d)
+		}()
+	}
+	wg.Wait()
+	return err
+}
+
+// stopped returns an error once the context is done or the fleet is closed.
+func (f *Fleet) stopped(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return ErrClientClosed
+	default:
+		return ctx.Err()
+	}
+}
+
+// connect creates the device's client and connects it, giving up when the
+// context is done.  If the fleet was closed while the device was connecting,
+// the client is closed right away.
+func (f *Fleet) connect(ctx context.Context, d *fleetDevice) {
+	d.setState(DeviceConnecting, nil)
+	c, err := newClient(d.config)
+	if err != nil {
+		d.setState(DeviceFailed, err)
+		return
+	}
+	if err = c.connect(ctx); err != nil {
+		c.Close()
+		d.setState(DeviceFailed, err)
+		return
+	}
+
+	f.lock.Lock()
+	d.lock.Lock()
+	d.client = c
+	d.lock.Unlock()
+	closed := f.stopped(context.Background()) != nil
+	f.lock.Unlock()
+	if closed {
+		c.Close()
+	}
+}
+
+// Devices lists the ids of every device in the fleet.
+func (f *Fleet) Devices() []wrp.DeviceID {
+	ids := make([]wrp.DeviceID, len(f.devices))
+	for i, d := range f.devices {
+		ids[i] = d.status.DeviceID
+	}
+	return ids
+}
+
+// Client gets the client for a device.  It is nil until the device has
+// connected.
+func (f *Fleet) Client(deviceID wrp.DeviceID) Client {
+	d, ok := f.byID[deviceID]
+	if !ok {
+		return nil
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.client
+}
+
+// Status reports on every device in the fleet, in device id order.
+func (f *Fleet) Status() []DeviceStatus {
+	statuses := make([]DeviceStatus, len(f.devices))
+	for i, d := range f.devices {
+		statuses[i] = d.getStatus()
+	}
+	return statuses
+}
+
+// Summary counts how many devices are in each DeviceState.
+func (f *Fleet) Summary() map[DeviceState]int {
+	summary := make(map[DeviceState]int)
+	for _, d := range f.devices {
+		summary[d.getStatus().State]++
+	}
+	return summary
+}
+
+// Close stops any devices from connecting and closes every client.
+func (f *Fleet) Close() error {
+	var errs errorList
+	f.once.Do(func() {
+		f.lock.Lock()
+		close(f.done)
+		f.lock.Unlock()
+
+		var (
+			wg   sync.WaitGroup
+			lock sync.Mutex
+		)
+		for _, d := range f.devices {
+			d.lock.Lock()
+			c := d.client
+			d.lock.Unlock()
+			if c == nil {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := c.Close(); err != nil {
+					lock.Lock()
+					errs = append(errs, err)
+					lock.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+	})
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// getStatus provides a copy of the device's status.
+func (d *fleetDevice) getStatus() DeviceStatus {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	status := d.status
+	if d.client != nil && status.State == DeviceConnected {
+		status.Hostname = d.client.Hostname()
+	}
+	return status
+}
+
+// setState moves the device to a new state.  A nil error leaves the last
+// error in place.
+func (d *fleetDevice) setState(state DeviceState, err error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.setStateLocked(state, err)
+}
+
+// setStateLocked moves the device to a new state.  The lock must be held.
+func (d *fleetDevice) setStateLocked(state DeviceState, err error) {
+	if d.status.State != state {
+		d.status.State = state
+		d.status.Since = time.Now()
+	}
+	if err != nil {
+		d.status.Err = err
+	}
+}
+
+// OnConnect marks the device as connected.
+func (d *fleetDevice) OnConnect(ConnectionEvent) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.status.Connects++
+	d.setStateLocked(DeviceConnected, nil)
+}
+
+// OnDisconnect marks the device as disconnected, or closed.
+func (d *fleetDevice) OnDisconnect(e ConnectionEvent) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if errors.Is(e.Err, ErrClientClosed) {
+		d.setStateLocked(DeviceClosed, nil)
+		return
+	}
+	d.status.Disconnects++
+	d.setStateLocked(DeviceDisconnected, e.Err)
+}
+
+// OnReconnect records why a reconnect attempt failed.
+func (d *fleetDevice) OnReconnect(e ConnectionEvent) {
+	if e.Err != nil {
+		d.setState(DeviceDisconnected, e.Err)
+	}
+}
+
+// OnRedirect does nothing.
+func (d *fleetDevice) OnRedirect(ConnectionEvent) {}
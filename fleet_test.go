@@ -0,0 +1,193 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+func TestNewFleet(t *testing.T) {
+	tests := []struct {
+		description string
+		config      FleetConfig
+		expectedErr error
+	}{
+		{
+			description: "no devices",
+			config:      FleetConfig{Template: clientConfig, FirstDeviceID: "mac:112233445566"},
+			expectedErr: errNoDevices,
+		},
+		{
+			description: "no ping miss handler",
+			config:      FleetConfig{Devices: 1, FirstDeviceID: "mac:112233445566"},
+			expectedErr: errNilHandlePingMiss,
+		},
+		{
+			description: "not a mac",
+			config:      FleetConfig{Template: clientConfig, Devices: 1, FirstDeviceID: "serial:1234"},
+			expectedErr: errInvalidFirstDevice,
+		},
+		{
+			description: "too many devices",
+			config:      FleetConfig{Template: clientConfig, Devices: 2, FirstDeviceID: "mac:ffffffffffff"},
+			expectedErr: errTooManyDevices,
+		},
+		{
+			description: "local addresses with a dialer",
+			config: FleetConfig{
+				Template:      ClientConfig{HandlePingMiss: clientConfig.HandlePingMiss, Dialer: &countingDialer{}},
+				Devices:       1,
+				FirstDeviceID: "mac:112233445566",
+				LocalAddrs:    []string{"127.0.0.1"},
+			},
+			expectedErr: errFleetLocalAddrs,
+		},
+		{
+			description: "invalid local address",
+			config:      FleetConfig{Template: clientConfig, Devices: 1, FirstDeviceID: "mac:112233445566", LocalAddrs: []string{"localhost"}},
+			expectedErr: errInvalidLocalAddr,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := NewFleet(tc.config)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestFleet(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var (
+		lock      sync.Mutex
+		firmwares = make(map[string]string)
+		hosts     = make(map[string]string)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		firmwares[r.Header.Get("X-Webpa-Device-Name")] = r.Header.Get("X-Webpa-Firmware-Name")
+		hosts[r.Header.Get("X-Webpa-Device-Name")], _, _ = net.SplitHostPort(r.RemoteAddr)
+		lock.Unlock()
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err = conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	template := clientConfig
+	template.DestinationURL = server.URL
+	fleet, err := NewFleet(FleetConfig{
+		Template:              template,
+		Devices:               3,
+		FirstDeviceID:         "mac:1122334455fe",
+		FirmwareNames:         []string{"one", "two"},
+		LocalAddrs:            []string{"127.0.0.1", "127.0.0.2"},
+		NewHandlers:           func(wrp.DeviceID) []HandlerConfig { return nil },
+		ConnectInterval:       time.Millisecond,
+		MaxConcurrentConnects: 2,
+	})
+	require.NoError(err)
+	assert.Equal([]wrp.DeviceID{"mac:1122334455fe", "mac:1122334455ff", "mac:112233445600"}, fleet.Devices())
+	assert.Equal(map[DeviceState]int{DevicePending: 3}, fleet.Summary())
+
+	// every device shares the same worker pools, encoders and decoders.
+	assert.Same(fleet.devices[0].config.WRPEncoderQueue.workers, fleet.devices[2].config.WRPEncoderQueue.workers)
+	assert.Same(fleet.devices[0].config.WRPEncoderQueue.codecs, fleet.devices[2].config.WRPDecoderQueue.codecs)
+
+	require.NoError(fleet.Start(context.Background()))
+	assert.ErrorIs(fleet.Start(context.Background()), errFleetStarted)
+	assert.Equal(map[DeviceState]int{DeviceConnected: 3}, fleet.Summary())
+	assert.Equal(map[string]string{
+		"mac:1122334455fe": "one",
+		"mac:1122334455ff": "two",
+		"mac:112233445600": "one",
+	}, firmwares)
+	assert.Equal(map[string]string{
+		"mac:1122334455fe": "127.0.0.1",
+		"mac:1122334455ff": "127.0.0.2",
+		"mac:112233445600": "127.0.0.1",
+	}, hosts)
+
+	for _, status := range fleet.Status() {
+		assert.Equal(1, status.Connects)
+		assert.Equal("127.0.0.1", status.Hostname)
+		assert.NotNil(fleet.Client(status.DeviceID))
+	}
+	assert.Nil(fleet.Client("mac:000000000000"))
+
+	require.NoError(fleet.Close())
+	assert.Equal(map[DeviceState]int{DeviceClosed: 3}, fleet.Summary())
+}
+
+func TestFleetFailedConnect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(StatusDeviceDisconnected)
+	}))
+	defer server.Close()
+
+	template := clientConfig
+	template.DestinationURL = server.URL
+	fleet, err := NewFleet(FleetConfig{Template: template, Devices: 1, FirstDeviceID: "mac:112233445566"})
+	require.NoError(t, err)
+	defer fleet.Close()
+
+	require.NoError(t, fleet.Start(context.Background()))
+	status := fleet.Status()[0]
+	assert.Equal(t, DeviceFailed, status.State)
+	assert.Error(t, status.Err)
+	assert.Nil(t, fleet.Client(status.DeviceID))
+}
+
+func TestFleetCloseWhileConnecting(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// never answer the handshake, so the device is stuck connecting.
+	connecting := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connecting <- struct{}{}
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	template := clientConfig
+	template.DestinationURL = server.URL
+	fleet, err := NewFleet(FleetConfig{Template: template, Devices: 1, FirstDeviceID: "mac:112233445566"})
+	require.NoError(err)
+
+	started := make(chan error, 1)
+	go func() {
+		started <- fleet.Start(context.Background())
+	}()
+	<-connecting
+	require.NoError(fleet.Close())
+	select {
+	case err = <-started:
+		assert.NoError(err)
+	case <-time.After(5 * time.Second):
+		require.Fail("closing the fleet did not stop the device connecting")
+	}
+	assert.Equal(map[DeviceState]int{DeviceFailed: 1}, fleet.Summary())
+}
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	// HandlersGroup is the fx value group of HandlerConfigs added to the
+	// Client provided by Module.
+	HandlersGroup = "kratos.handlers"
+
+	// NamedHandlersGroup is the fx value group of NamedHandlers bound to the
+	// handler routes of the Config.
+	NamedHandlersGroup = "kratos.namedHandlers"
+
+	// ListenersGroup is the fx value group of ClientListeners given to the
+	// Client provided by Module.
+	ListenersGroup = "kratos.listeners"
+)
+
+// Module provides a Client to an fx application.  The Client is built from
+// the Config in the container, connects when the application starts, and is
+// closed when the application stops.
+// Its handlers come from the HandlersGroup and NamedHandlersGroup value
+// groups, and its logger is the *zap.Logger in the container, if there is
+// one.  A HandlePingMiss must also be provided.
+var Module = fx.Module("kratos",
+	fx.Provide(ProvideClient),
+)
+
+// NamedHandler is a DownstreamHandler that the handler routes of a Config
+// refer to by Name.
+type NamedHandler struct {
+	Name    string
+	Handler DownstreamHandler
+}
+
+// ClientIn is everything ProvideClient takes from the fx container.
+type ClientIn struct {
+	fx.In
+
+	Config         Config
+	HandlePingMiss HandlePingMiss
+	Lifecycle      fx.Lifecycle
+	Logger         *zap.Logger      `optional:"true"`
+	TokenAcquirer  TokenAcquirer    `optional:"true"`
+	Metrics        *Metrics         `optional:"true"`
+	Handlers       []HandlerConfig  `group:"kratos.handlers"`
+	NamedHandlers  []NamedHandler   `group:"kratos.namedHandlers"`
+	Listeners      []ClientListener `group:"kratos.listeners"`
+}
+
+// ProvideClient creates a Client from the Config in the container.  The
+// Client connects when the application starts, within the start timeout, and
+// is closed when the application stops.  Until then, handlers can be added to
+// it, but messages it sends can't be written.  Handler routes in the Config
+// are bound to the NamedHandlers by name, and the HandlerConfigs in the
+// HandlersGroup are added after them.
+func ProvideClient(in ClientIn) (Client, error) {
+	handlers := make(map[string]DownstreamHandler, len(in.NamedHandlers))
+	for _, h := range in.NamedHandlers {
+		handlers[h.Name] = h.Handler
+	}
+
+	config, err := in.Config.ClientConfig(handlers)
+	if err != nil {
+		return nil, err
+	}
+	config.Handlers = append(config.Handlers, in.Handlers...)
+	config.HandlePingMiss = in.HandlePingMiss
+	config.ClientLogger = in.Logger
+	config.TokenAcquirer = in.TokenAcquirer
+	config.Metrics = in.Metrics
+	config.Listeners = in.Listeners
+
+	client, err := newClient(config)
+	if err != nil {
+		return nil, err
+	}
+	in.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := client.connect(ctx); err != nil {
+				client.Close()
+				return err
+			}
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			return client.Close()
+		},
+	})
+	return client, nil
+}
+
+// AsHandler annotates a constructor of a HandlerConfig so that its result is
+// added to the HandlersGroup.
+func AsHandler(f any) any {
+	return fx.Annotate(f, fx.ResultTags(`group:"kratos.handlers"`))
+}
+
+// AsNamedHandler annotates a constructor of a NamedHandler so that its result
+// is added to the NamedHandlersGroup.
+func AsNamedHandler(f any) any {
+	return fx.Annotate(f, fx.ResultTags(`group:"kratos.namedHandlers"`))
+}
+
+// AsListener annotates a constructor of a ClientListener so that its result
+// is added to the ListenersGroup.
+func AsListener(f any) any {
+	return fx.Annotate(f, fx.ResultTags(`group:"kratos.listeners"`))
+}
@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v3"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+// channelHandler passes every message it handles to a channel.
+type channelHandler chan *wrp.Message
+
+func (c channelHandler) HandleMessage(msg *wrp.Message) *wrp.Message {
+	c <- msg
+	return nil
+}
+
+func (c channelHandler) Close() {}
+
+func TestModule(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// echo every message back, so the client handles what it sends.
+	var connects atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connects.Add(1)
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(messageType, data)
+		}
+	}))
+	defer server.Close()
+
+	named := make(channelHandler, 1)
+	grouped := make(channelHandler, 1)
+	recorder, listener := newRecordingListener()
+
+	var client Client
+	app := fxtest.New(t,
+		Module,
+		fx.Supply(
+			Config{
+				DeviceName:     clientConfig.DeviceName,
+				DestinationURL: server.URL,
+				Handlers:       []RouteConfig{{Regexp: "/named", Handler: "named"}},
+			},
+			HandlePingMiss(func() error { return nil }),
+			zap.NewNop(),
+		),
+		fx.Provide(
+			AsNamedHandler(func() NamedHandler {
+				return NamedHandler{Name: "named", Handler: named}
+			}),
+			AsHandler(func() HandlerConfig {
+				return HandlerConfig{Regexp: "/grouped", Handler: grouped}
+			}),
+			AsListener(func() ClientListener { return listener }),
+		),
+		fx.Populate(&client),
+	)
+	assert.Zero(connects.Load(), "the client connected before the application started")
+	app.RequireStart()
+	assert.Equal(int32(1), connects.Load())
+
+	for _, dest := range []string{"/named", "/grouped"} {
+		client.Send(&wrp.Message{
+			Type:        wrp.SimpleEventMessageType,
+			Source:      "dns:talaria",
+			Destination: clientConfig.DeviceName + dest,
+		})
+	}
+	for _, handler := range []channelHandler{named, grouped} {
+		select {
+		case msg := <-handler:
+			assert.Equal("dns:talaria", msg.Source)
+		case <-time.After(5 * time.Second):
+			require.Fail("handler was not called")
+		}
+	}
+
+	app.RequireStop()
+	disconnects := recorder.get("disconnect")
+	require.Len(disconnects, 1)
+	assert.ErrorIs(disconnects[0].Err, ErrClientClosed)
+}
+
+func TestModuleErrors(t *testing.T) {
+	tests := []struct {
+		description string
+		config      Config
+		expectedErr error
+	}{
+		{
+			description: "invalid config",
+			config:      Config{DestinationURL: "http://talaria"},
+			expectedErr: errRequired,
+		},
+		{
+			description: "unbound handler",
+			config: Config{
+				DeviceName:     clientConfig.DeviceName,
+				DestinationURL: "http://talaria",
+				Handlers:       []RouteConfig{{Regexp: "/config", Handler: "config"}},
+			},
+			expectedErr: errUnboundHandler,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			app := fx.New(
+				Module,
+				fx.Supply(tc.config, HandlePingMiss(func() error { return nil })),
+				fx.NopLogger,
+				fx.Invoke(func(Client) {}),
+			)
+			assert.ErrorIs(t, app.Err(), tc.expectedErr)
+		})
+	}
+}
+
+func TestModuleStartError(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	recorder, listener := newRecordingListener()
+	app := fx.New(
+		Module,
+		fx.Supply(
+			Config{DeviceName: clientConfig.DeviceName, DestinationURL: server.URL},
+			HandlePingMiss(func() error { return nil }),
+		),
+		fx.Provide(AsListener(func() ClientListener { return listener })),
+		fx.NopLogger,
+		fx.Invoke(func(Client) {}),
+	)
+	assert.NoError(app.Err())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Error(app.Start(ctx))
+	assert.Empty(recorder.get("connect"))
+	assert.Empty(recorder.get("disconnect"))
+}
@@ -5,14 +5,26 @@ package kratos
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/xmidt-org/wrp-go/v3"
 	"go.uber.org/zap"
 	"golang.org/x/sync/semaphore"
 )
 
+var (
+	// ErrHandlerPanic is the error in the response sent when a handler
+	// panics.
+	ErrHandlerPanic = errors.New("handler panicked")
+
+	// ErrHandlerTimeout is the error in the response sent when a handler
+	// takes longer than its timeout.
+	ErrHandlerTimeout = errors.New("handler timed out")
+)
+
 // downstreamSender sends wrp messages to components downstream.
 type downstreamSender interface {
 	Send(DownstreamHandler, *wrp.Message)
@@ -31,54 +43,71 @@ type sendInfo struct {
 type downstreamSenderQueue struct {
 	incoming chan sendInfo
 	sendFunc sendWRPFunc
+	policy   OverflowPolicy
+	metrics  *Metrics
 	workers  *semaphore.Weighted
+	timeout  time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
 	wg       sync.WaitGroup
 	logger   *zap.Logger
 	once     sync.Once
 	closed   atomic.Value
+
+	// connection describes the client's connection, for handlers.
+	connection func() Connection
 }
 
 // NewDownstreamSender creates a new downstreamSenderQueue for asynchronously
 // sending wrp messages downstream.
-func NewDownstreamSender(senderFunc sendWRPFunc, maxWorkers int, queueSize int, logger *zap.Logger) *downstreamSenderQueue {
-	size := queueSize
+func NewDownstreamSender(senderFunc sendWRPFunc, config QueueConfig, metrics *Metrics, logger *zap.Logger) *downstreamSenderQueue {
+	size := config.Size
 	if size < minQueueSize {
 		size = minQueueSize
 	}
-	numWorkers := maxWorkers
-	if numWorkers < minWorkers {
-		numWorkers = minWorkers
-	}
 	d := downstreamSenderQueue{
 		incoming: make(chan sendInfo, size),
 		sendFunc: senderFunc,
-		workers:  semaphore.NewWeighted(int64(numWorkers)),
+		policy:   config.Overflow,
+		metrics:  metrics,
+		workers:  config.newWorkers(),
+		timeout:  config.Timeout,
 		logger:   logger,
 	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
 	d.wg.Add(1)
 	go d.startSending()
 	return &d
 }
 
 // Send adds the wrp message and the handler to use for it to the queue of
-// messages to be sent.  It will block if the queue is full.  This should not
-// be called after Close().
+// messages to be sent.  When the queue is full, the queue's OverflowPolicy is
+// followed.  This should not be called after Close().
 func (d *downstreamSenderQueue) Send(handler DownstreamHandler, msg *wrp.Message) {
 	switch d.closed.Load() {
 	case true:
 		d.logger.Error("Failed to queue message. DownstreamSenderQueue is no longer accepting messages.")
 	default:
-		d.incoming <- sendInfo{handler: handler, msg: msg}
+		err := enqueue(context.Background(), d.incoming, sendInfo{handler: handler, msg: msg}, d.policy, func(sendInfo) {
+			d.logger.Warn("Dropped oldest message from DownstreamSenderQueue.")
+			d.metrics.droppedOldest(StageHandler)
+		})
+		d.metrics.enqueueResult(StageHandler, err)
+		if err != nil {
+			d.logger.Error("Failed to queue message.", zap.Error(err))
+		}
 	}
 }
 
-// Close closes the queue channel and then blocks until all remaining messages
-// have been sent.
+// Close closes the queue channel, blocks until all remaining messages have
+// been sent, and then cancels the context given to handlers.  Messages still
+// in the queue are handled with a live context, like any other.
 func (d *downstreamSenderQueue) Close() {
 	d.once.Do(func() {
 		d.closed.Store(true)
 		close(d.incoming)
 		d.wg.Wait()
+		d.cancel()
 	})
 }
 
@@ -89,20 +118,34 @@ func (d *downstreamSenderQueue) startSending() {
 	ctx := context.Background()
 	defer d.wg.Done()
 	for i := range d.incoming {
+		d.metrics.dequeued(StageHandler)
 		d.workers.Acquire(ctx, 1)
 		d.wg.Add(1)
 		go d.send(i)
 	}
 }
 
-// send calls HandleMessage() on the handler that the message should be sent to.
+// send calls HandleMessage() on the handler that the message should be sent
+// to.  If the handler fails, panics or times out, the failure is logged and
+// counted, and an error response is sent back to the message's source
+// instead, if the message has a transaction.
 func (d *downstreamSenderQueue) send(s sendInfo) {
 	defer d.wg.Done()
 	defer d.workers.Release(1)
+	d.metrics.workerStarted(StageHandler)
+	defer d.metrics.workerDone(StageHandler)
 
 	d.logger.Debug("Sending message downstream...")
 
-	response := s.handler.HandleMessage(s.msg)
+	start := time.Now()
+	response, err := d.handle(s)
+	d.metrics.handled(s.msg.Type, time.Since(start))
+	if err != nil {
+		d.metrics.handlerError()
+		d.logger.Error("Handler failed", zap.String("destination", s.msg.Destination),
+			zap.String("transaction", s.msg.TransactionUUID), zap.Error(err))
+	}
+	response = respond(s.msg)(response, err)
 	if response != nil {
 		d.logger.Debug("Downstream returned a response")
 		d.sendFunc(response)
@@ -111,3 +154,55 @@ func (d *downstreamSenderQueue) send(s sendInfo) {
 
 	d.logger.Debug("Downstream Message Sent")
 }
+
+// handle calls the handler, giving up once the handler's timeout has passed.
+// A handler that is given up on keeps running, but no longer holds a worker.
+func (d *downstreamSenderQueue) handle(s sendInfo) (*wrp.Message, error) {
+	ctx := withTrace(d.ctx, newTrace(s.msg))
+	if d.connection != nil {
+		ctx = withConnection(ctx, d.connection())
+	}
+	timeout := d.timeout
+	if t, ok := s.handler.(*timeoutHandler); ok {
+		timeout = t.timeout
+		s.handler = t.DownstreamHandler
+	}
+	if timeout <= 0 {
+		return d.recoverHandle(ctx, s)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		response *wrp.Message
+		err      error
+	}
+	results := make(chan result, 1)
+	go func() {
+		response, err := d.recoverHandle(ctx, s)
+		results <- result{response: response, err: err}
+	}()
+
+	select {
+	case r := <-results:
+		return r.response, r.err
+	case <-ctx.Done():
+		d.metrics.handlerTimeout()
+		d.logger.Error("Handler timed out", zap.String("destination", s.msg.Destination), zap.Duration("timeout", timeout))
+		return nil, ErrHandlerTimeout
+	}
+}
+
+// recoverHandle calls the handler, recovering from any panic.  The panic is
+// logged, but not put in the error returned, since that is sent upstream.
+func (d *downstreamSenderQueue) recoverHandle(ctx context.Context, s sendInfo) (response *wrp.Message, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d.metrics.handlerPanic()
+			d.logger.Error("Recovered from handler panic", zap.String("destination", s.msg.Destination), zap.Any("panic", r), zap.Stack("stack"))
+			response, err = nil, ErrHandlerPanic
+		}
+	}()
+	return handleContext(ctx, s.handler, s.msg)
+}
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v3"
+	"go.uber.org/zap"
+)
+
+// panicHandler panics on every message.
+type panicHandler struct{}
+
+func (panicHandler) HandleMessage(*wrp.Message) *wrp.Message {
+	panic("boom")
+}
+
+func (panicHandler) Close() {}
+
+// blockingHandler doesn't return until release is closed.
+type blockingHandler struct {
+	release chan struct{}
+}
+
+func (b *blockingHandler) HandleMessage(msg *wrp.Message) *wrp.Message {
+	<-b.release
+	return msg
+}
+
+func (b *blockingHandler) Close() {}
+
+func TestDownstreamSenderRecovery(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	metrics, err := NewMetrics(prometheus.NewPedanticRegistry())
+	require.NoError(err)
+	responses := make(chan *wrp.Message, 10)
+	sender := NewDownstreamSender(func(msg *wrp.Message) { responses <- msg },
+		QueueConfig{MaxWorkers: 1, Timeout: 20 * time.Millisecond}, metrics, zap.NewNop())
+	defer sender.Close()
+
+	msg := &wrp.Message{
+		Type:            wrp.SimpleRequestResponseMessageType,
+		Source:          "dns:talaria",
+		Destination:     "mac:ffffff112233/config",
+		TransactionUUID: "123",
+	}
+
+	sender.Send(panicHandler{}, msg)
+	response := <-responses
+	assert.Equal("dns:talaria", response.Destination)
+	assert.Equal("mac:ffffff112233/config", response.Source)
+	assert.Equal("123", response.TransactionUUID)
+	assert.Equal(int64(http.StatusInternalServerError), *response.Status)
+	assert.Equal(1.0, testutil.ToFloat64(metrics.handlerPanics))
+
+	// a hung handler gives up its worker, so the next message is handled.
+	blocked := &blockingHandler{release: make(chan struct{})}
+	defer close(blocked.release)
+	sender.Send(blocked, msg)
+	response = <-responses
+	assert.Equal(int64(http.StatusGatewayTimeout), *response.Status)
+	assert.Equal(1.0, testutil.ToFloat64(metrics.handlerTimeouts))
+
+	sender.Send(&echoHandler{}, msg)
+	assert.Same(msg, <-responses)
+}
+
+func TestDownstreamSenderRecoveryEvent(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	metrics, err := NewMetrics(prometheus.NewPedanticRegistry())
+	require.NoError(err)
+	responses := make(chan *wrp.Message, 10)
+	sender := NewDownstreamSender(func(msg *wrp.Message) { responses <- msg },
+		QueueConfig{MaxWorkers: 1}, metrics, zap.NewNop())
+
+	// events have no transaction, so failures are only logged and counted.
+	sender.Send(panicHandler{}, &wrp.Message{
+		Type:        wrp.SimpleEventMessageType,
+		Source:      "dns:talaria",
+		Destination: "event:device-status/mac:ffffff112233/online",
+	})
+	sender.Close()
+	assert.Empty(responses)
+	assert.Equal(1.0, testutil.ToFloat64(metrics.handlerPanics))
+	assert.Equal(1.0, testutil.ToFloat64(metrics.handlerErrors))
+}
+
+func TestDownstreamSenderHandlerTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	responses := make(chan *wrp.Message, 10)
+	sender := NewDownstreamSender(func(msg *wrp.Message) { responses <- msg },
+		QueueConfig{}, nil, zap.NewNop())
+	defer sender.Close()
+
+	blocked := &blockingHandler{release: make(chan struct{})}
+	defer close(blocked.release)
+	sender.Send(&timeoutHandler{DownstreamHandler: blocked, timeout: 20 * time.Millisecond}, &wrp.Message{TransactionUUID: "123"})
+	select {
+	case response := <-responses:
+		assert.Equal(int64(http.StatusGatewayTimeout), *response.Status)
+	case <-time.After(time.Second):
+		assert.Fail("the handler's timeout was not used")
+	}
+}
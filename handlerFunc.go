@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+// HandlerFunc lets a function be used as a DownstreamHandler.  Closing it
+// does nothing.
+type HandlerFunc func(msg *wrp.Message) *wrp.Message
+
+// HandleMessage calls f with the message.
+func (f HandlerFunc) HandleMessage(msg *wrp.Message) *wrp.Message {
+	return f(msg)
+}
+
+// Close does nothing.
+func (f HandlerFunc) Close() {}
+
+// ContextHandlerFunc lets a function be used as a ContextHandler.  It is also
+// a DownstreamHandler, so it can be registered without AdaptContextHandler.
+// Closing it does nothing.
+type ContextHandlerFunc func(ctx context.Context, msg *wrp.Message) (*wrp.Message, error)
+
+// HandleMessageContext calls f with the context and message.
+func (f ContextHandlerFunc) HandleMessageContext(ctx context.Context, msg *wrp.Message) (*wrp.Message, error) {
+	return f(ctx, msg)
+}
+
+// HandleMessage calls f with a background context, turning an error into an
+// error response if the message has a transaction.
+func (f ContextHandlerFunc) HandleMessage(msg *wrp.Message) *wrp.Message {
+	return respond(msg)(f(context.Background(), msg))
+}
+
+// Close does nothing.
+func (f ContextHandlerFunc) Close() {}
+
+// Reply creates an empty response to the message, of the same type, from the
+// message's destination back to its source.
+func Reply(msg *wrp.Message) *wrp.Message {
+	return &wrp.Message{
+		Type:            msg.Type,
+		Source:          msg.Destination,
+		Destination:     msg.Source,
+		TransactionUUID: msg.TransactionUUID,
+	}
+}
+
+// ReplyWithStatus responds to every message with the status code given.
+func ReplyWithStatus(statusCode int64) HandlerFunc {
+	return func(msg *wrp.Message) *wrp.Message {
+		response := Reply(msg)
+		response.SetStatus(statusCode)
+		return response
+	}
+}
+
+// Echo responds to every message with the message's own payload.
+func Echo() HandlerFunc {
+	return func(msg *wrp.Message) *wrp.Message {
+		response := Reply(msg)
+		response.ContentType = msg.ContentType
+		response.Payload = msg.Payload
+		return response
+	}
+}
+
+// StaticPayload responds to every message with the payload given.
+func StaticPayload(contentType string, payload []byte) HandlerFunc {
+	return func(msg *wrp.Message) *wrp.Message {
+		response := Reply(msg)
+		response.ContentType = contentType
+		response.Payload = payload
+		return response
+	}
+}
+
+// ForwardTo passes every message to the channel, without responding.  It
+// waits for the channel to take the message, giving up with an error when
+// the context is done.
+func ForwardTo(messages chan<- *wrp.Message) ContextHandlerFunc {
+	return func(ctx context.Context, msg *wrp.Message) (*wrp.Message, error) {
+		select {
+		case messages <- msg:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+func TestRouteBuilders(t *testing.T) {
+	assert := assert.New(t)
+
+	msg := &wrp.Message{
+		Type:            wrp.RetrieveMessageType,
+		Source:          "dns:talaria",
+		Destination:     "mac:ffffff112233/config",
+		TransactionUUID: "123",
+		ContentType:     "text/plain",
+		Payload:         []byte("hello"),
+	}
+
+	response := ReplyWithStatus(http.StatusAccepted).HandleMessage(msg)
+	assert.Equal(wrp.RetrieveMessageType, response.Type)
+	assert.Equal("dns:talaria", response.Destination)
+	assert.Equal("mac:ffffff112233/config", response.Source)
+	assert.Equal("123", response.TransactionUUID)
+	assert.Equal(int64(http.StatusAccepted), *response.Status)
+
+	response = Echo().HandleMessage(msg)
+	assert.Equal("text/plain", response.ContentType)
+	assert.Equal("hello", string(response.Payload))
+	assert.Equal("dns:talaria", response.Destination)
+
+	response = StaticPayload("application/json", []byte(`{"ok":true}`)).HandleMessage(msg)
+	assert.Equal("application/json", response.ContentType)
+	assert.Equal(`{"ok":true}`, string(response.Payload))
+}
+
+func TestForwardTo(t *testing.T) {
+	assert := assert.New(t)
+
+	messages := make(chan *wrp.Message, 1)
+	handler := ForwardTo(messages)
+	msg := &wrp.Message{TransactionUUID: "123"}
+	assert.Nil(handler.HandleMessage(msg))
+	assert.Same(msg, <-messages)
+
+	// a full channel is given up on when the context is done.
+	messages <- msg
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := handler.HandleMessageContext(ctx, &wrp.Message{})
+	assert.ErrorIs(err, context.Canceled)
+}
+
+func TestHandlerRegistryAddFunc(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	registry, err := NewHandlerRegistry(nil)
+	require.NoError(err)
+	require.NoError(registry.AddFunc("/status", ReplyWithStatus(http.StatusOK)))
+	assert.Equal(errInvalidHandler{}, registry.AddFunc("/nil", nil))
+
+	handler, err := registry.GetHandler("/status")
+	require.NoError(err)
+	assert.Equal(int64(http.StatusOK), *handler.HandleMessage(&wrp.Message{}).Status)
+	assert.Len(registry.Handlers(), 1)
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The headers a device connects to XMiDT with.
+const (
+	DeviceNameHeader       = "X-Webpa-Device-Name"
+	FirmwareNameHeader     = "X-Webpa-Firmware-Name"
+	ModelNameHeader        = "X-Webpa-Model-Name"
+	ManufacturerHeader     = "X-Webpa-Manufacturer"
+	BootTimeHeader         = "X-Webpa-Boot-Time"
+	LastRebootReasonHeader = "X-Webpa-Last-Reboot-Reason"
+	InterfaceUsedHeader    = "X-Webpa-Interface-Used"
+	ProtocolHeader         = "X-Webpa-Protocol"
+	ConveyHeader           = "X-Webpa-Convey"
+	UserAgentHeader        = "User-Agent"
+)
+
+// DefaultProtocol is the X-Webpa-Protocol sent when ClientConfig.Protocol is
+// not set.  It is what parodus, the agent on real devices, sends.
+const DefaultProtocol = "PARODUS-2.0"
+
+// used to track everything that we want to know about the client headers
+type clientHeader struct {
+	deviceName       string
+	firmwareName     string
+	modelName        string
+	manufacturer     string
+	bootTime         time.Time
+	lastRebootReason string
+	interfaceUsed    string
+	protocol         string
+	userAgent        string
+	convey           string
+	extra            http.Header
+}
+
+// newClientHeader collects the headers described by the ClientConfig.  The
+// boot time defaults to now, so that it stays the same across reconnects.
+func newClientHeader(config ClientConfig) (*clientHeader, error) {
+	h := &clientHeader{
+		deviceName:       config.DeviceName,
+		firmwareName:     config.FirmwareName,
+		modelName:        config.ModelName,
+		manufacturer:     config.Manufacturer,
+		bootTime:         config.BootTime,
+		lastRebootReason: config.LastRebootReason,
+		interfaceUsed:    config.InterfaceUsed,
+		protocol:         config.Protocol,
+		userAgent:        config.UserAgent,
+		extra:            config.Headers.Clone(),
+	}
+	if h.bootTime.IsZero() {
+		h.bootTime = time.Now()
+	}
+	if h.protocol == "" {
+		h.protocol = DefaultProtocol
+	}
+	if h.userAgent == "" {
+		h.userAgent = "WebPA-1.6(" + h.firmwareName + ";" + h.modelName + "/" + h.manufacturer + ";)"
+	}
+
+	convey, err := encodeConvey(config.Convey)
+	if err != nil {
+		return nil, err
+	}
+	h.convey = convey
+	return h, nil
+}
+
+// encodeConvey encodes the convey as base64 JSON.  An empty convey isn't
+// sent.
+func encodeConvey(convey map[string]any) (string, error) {
+	if len(convey) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(convey)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode convey: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// apply sets the headers on a connection request.  The extra headers are set
+// last, so they replace any of the others with the same name.
+func (h *clientHeader) apply(headers http.Header) {
+	headers.Set(DeviceNameHeader, h.deviceName)
+	headers.Set(FirmwareNameHeader, h.firmwareName)
+	headers.Set(ModelNameHeader, h.modelName)
+	headers.Set(ManufacturerHeader, h.manufacturer)
+	headers.Set(BootTimeHeader, strconv.FormatInt(h.bootTime.Unix(), 10))
+	headers.Set(ProtocolHeader, h.protocol)
+	headers.Set(UserAgentHeader, h.userAgent)
+	if h.lastRebootReason != "" {
+		headers.Set(LastRebootReasonHeader, h.lastRebootReason)
+	}
+	if h.interfaceUsed != "" {
+		headers.Set(InterfaceUsedHeader, h.interfaceUsed)
+	}
+	if h.convey != "" {
+		headers.Set(ConveyHeader, h.convey)
+	}
+	for name, values := range h.extra {
+		headers[http.CanonicalHeaderKey(name)] = append([]string{}, values...)
+	}
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHeaderServer accepts connections, passing the headers of each to the
+// channel.
+func newHeaderServer(headers chan<- http.Header) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		upgrader.Upgrade(w, r, nil)
+	}))
+}
+
+func TestClientHeaders(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	headers := make(chan http.Header, 1)
+	server := newHeaderServer(headers)
+	defer server.Close()
+
+	bootTime := time.Unix(1700000000, 0)
+	config := clientConfig
+	config.DestinationURL = server.URL
+	config.BootTime = bootTime
+	config.LastRebootReason = "factory-reset"
+	config.InterfaceUsed = "erouter0"
+	config.Protocol = "PARODUS-2.0-1.1.4"
+	config.Convey = map[string]any{"hw-model": "TG1682", "webpa-interface-used": "erouter0"}
+	config.Headers = http.Header{"x-custom": {"a", "b"}, "X-Webpa-Model-Name": {"replaced"}}
+	testClient, err := NewClient(config)
+	require.NoError(err)
+	defer testClient.Close()
+
+	h := <-headers
+	assert.Equal(config.DeviceName, h.Get(DeviceNameHeader))
+	assert.Equal(strconv.FormatInt(bootTime.Unix(), 10), h.Get(BootTimeHeader))
+	assert.Equal("factory-reset", h.Get(LastRebootReasonHeader))
+	assert.Equal("erouter0", h.Get(InterfaceUsedHeader))
+	assert.Equal("PARODUS-2.0-1.1.4", h.Get(ProtocolHeader))
+	assert.Equal("WebPA-1.6("+config.FirmwareName+";"+config.ModelName+"/"+config.Manufacturer+";)", h.Get(UserAgentHeader))
+	assert.Equal([]string{"a", "b"}, h.Values("X-Custom"))
+	assert.Equal([]string{"replaced"}, h.Values(ModelNameHeader))
+
+	data, err := base64.StdEncoding.DecodeString(h.Get(ConveyHeader))
+	require.NoError(err)
+	var convey map[string]any
+	require.NoError(json.Unmarshal(data, &convey))
+	assert.Equal(config.Convey, convey)
+}
+
+func TestClientHeaderDefaults(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	headers := make(chan http.Header, 2)
+	server := newHeaderServer(headers)
+	defer server.Close()
+
+	config := clientConfig
+	config.DestinationURL = server.URL
+	config.UserAgent = "emulator/1.0"
+	before := time.Now().Unix()
+	testClient, err := NewClient(config)
+	require.NoError(err)
+	defer testClient.Close()
+
+	h := <-headers
+	bootTime, err := strconv.ParseInt(h.Get(BootTimeHeader), 10, 64)
+	require.NoError(err)
+	assert.GreaterOrEqual(bootTime, before)
+	assert.Equal(DefaultProtocol, h.Get(ProtocolHeader))
+	assert.Equal("emulator/1.0", h.Get(UserAgentHeader))
+	assert.Empty(h.Values(ConveyHeader))
+	assert.Empty(h.Values(LastRebootReasonHeader))
+	assert.Empty(h.Values(InterfaceUsedHeader))
+
+	config.Convey = map[string]any{"bad": make(chan int)}
+	_, err = NewClient(config)
+	assert.Error(err)
+}
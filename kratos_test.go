@@ -104,6 +104,16 @@ func (m *mockConnection) WriteMessage(messageType int, data []byte) error {
 	return arguments.Error(0)
 }
 
+func (m *mockConnection) SetWriteDeadline(t time.Time) error {
+	arguments := m.Called(t)
+	return arguments.Error(0)
+}
+
+func (m *mockConnection) SetReadDeadline(t time.Time) error {
+	arguments := m.Called(t)
+	return arguments.Error(0)
+}
+
 func (m *mockConnection) ReadMessage() (messageType int, p []byte, err error) {
 	arguments := m.Called()
 	return arguments.Int(0), arguments.Get(1).([]byte), arguments.Error(2)
@@ -257,6 +267,7 @@ func TestBadHandshake(t *testing.T) {
 // test the happy-path of sending a message through a websocket
 func TestSend(t *testing.T) {
 	fakeConn := &mockConnection{}
+	fakeConn.On("SetWriteDeadline", mock.AnythingOfType("time.Time")).Return(nil).Once()
 	fakeConn.On("WriteMessage", websocket.BinaryMessage, mock.AnythingOfType("[]uint8")).Return(nil).Once()
 
 	myMessage := wrp.Message{
@@ -266,11 +277,11 @@ func TestSend(t *testing.T) {
 	}
 	logger := sallust.Default()
 
-	sender := NewSender(fakeConn, 1, 1, logger)
-	encoder := NewEncoderSender(sender, 1, 1, logger)
+	sender := NewSender(fakeConn, QueueConfig{MaxWorkers: 1, Size: 1}, WriteConfig{}, nil, logger)
+	encoder := NewEncoderSender(sender, QueueConfig{MaxWorkers: 1, Size: 1}, nil, logger)
 	testClient := &client{
 		encoderSender: encoder,
-		connection:    fakeConn,
+		connection:    newManagedConnection(fakeConn),
 		logger:        logger,
 	}
 
@@ -283,15 +294,16 @@ func TestSend(t *testing.T) {
 // test what happens when a websocket fails to write a message
 func TestSendBrokenWriteMessage(t *testing.T) {
 	fakeConn := &mockConnection{}
+	fakeConn.On("SetWriteDeadline", mock.AnythingOfType("time.Time")).Return(nil).Once()
 	fakeConn.On("WriteMessage", websocket.BinaryMessage, mock.AnythingOfType("[]uint8")).Return(ErrFoo).Once()
 
 	logger := sallust.Default()
 
-	sender := NewSender(fakeConn, 1, 1, logger)
-	encoder := NewEncoderSender(sender, 1, 1, logger)
+	sender := NewSender(fakeConn, QueueConfig{MaxWorkers: 1, Size: 1}, WriteConfig{}, nil, logger)
+	encoder := NewEncoderSender(sender, QueueConfig{MaxWorkers: 1, Size: 1}, nil, logger)
 	testClient := &client{
 		encoderSender: encoder,
-		connection:    fakeConn,
+		connection:    newManagedConnection(fakeConn),
 		logger:        logger,
 	}
 
@@ -307,25 +319,26 @@ func TestClose(t *testing.T) {
 	require := require.New(t)
 
 	fakeConn := &mockConnection{}
+	fakeConn.On("SetReadDeadline", mock.AnythingOfType("time.Time")).Return(nil).Once()
 	fakeConn.On("Close").Return(nil).Once()
 
 	logger := sallust.Default()
 
-	sender := NewSender(fakeConn, 1, 1, logger)
-	encoder := NewEncoderSender(sender, 1, 1, logger)
+	sender := NewSender(fakeConn, QueueConfig{MaxWorkers: 1, Size: 1}, WriteConfig{}, nil, logger)
+	encoder := NewEncoderSender(sender, QueueConfig{MaxWorkers: 1, Size: 1}, nil, logger)
 	handlers, err := NewHandlerRegistry([]HandlerConfig{})
 	require.NoError(err)
 	rh := NewRegistryHandler(
 		func(message *wrp.Message) {},
 		handlers,
-		NewDownstreamSender(func(message *wrp.Message) {}, 1, 1, logger),
-		1, 1, "mac:deadbeefcafe", logger)
-	decoder := NewDecoderSender(rh, 1, 1, logger)
+		NewDownstreamSender(func(message *wrp.Message) {}, QueueConfig{MaxWorkers: 1, Size: 1}, nil, logger),
+		QueueConfig{MaxWorkers: 1, Size: 1}, "mac:deadbeefcafe", nil, logger)
+	decoder := NewDecoderSender(rh, QueueConfig{MaxWorkers: 1, Size: 1}, nil, logger)
 
 	testClient := &client{
 		encoderSender: encoder,
 		decoderSender: decoder,
-		connection:    fakeConn,
+		connection:    newManagedConnection(fakeConn),
 		logger:        logger,
 		done:          make(chan struct{}, 1),
 	}
@@ -341,24 +354,25 @@ func TestCloseBroken(t *testing.T) {
 	require := require.New(t)
 	fakeConn := &mockConnection{}
 
+	fakeConn.On("SetReadDeadline", mock.AnythingOfType("time.Time")).Return(nil).Once()
 	fakeConn.On("Close").Return(ErrFoo).Once()
 
 	logger := sallust.Default()
-	sender := NewSender(fakeConn, 1, 1, logger)
-	encoder := NewEncoderSender(sender, 1, 1, logger)
+	sender := NewSender(fakeConn, QueueConfig{MaxWorkers: 1, Size: 1}, WriteConfig{}, nil, logger)
+	encoder := NewEncoderSender(sender, QueueConfig{MaxWorkers: 1, Size: 1}, nil, logger)
 	handlers, err := NewHandlerRegistry([]HandlerConfig{})
 	require.NoError(err)
 	rh := NewRegistryHandler(
 		func(message *wrp.Message) {},
 		handlers,
-		NewDownstreamSender(func(message *wrp.Message) {}, 1, 1, logger),
-		1, 1, "mac:deadbeefcafe", logger)
-	decoder := NewDecoderSender(rh, 1, 1, logger)
+		NewDownstreamSender(func(message *wrp.Message) {}, QueueConfig{MaxWorkers: 1, Size: 1}, nil, logger),
+		QueueConfig{MaxWorkers: 1, Size: 1}, "mac:deadbeefcafe", nil, logger)
+	decoder := NewDecoderSender(rh, QueueConfig{MaxWorkers: 1, Size: 1}, nil, logger)
 
 	testClient := &client{
 		encoderSender: encoder,
 		decoderSender: decoder,
-		connection:    fakeConn,
+		connection:    newManagedConnection(fakeConn),
 		logger:        logger,
 		done:          make(chan struct{}, 1),
 	}
@@ -368,6 +382,66 @@ func TestCloseBroken(t *testing.T) {
 	fakeConn.AssertExpectations(t)
 }
 
+// test that responses from handlers still running when the client is closed
+// are written before the websocket is closed
+func TestCloseDrainsHandlers(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	responses := make(chan *wrp.Message, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var buf bytes.Buffer
+		wrp.NewEncoder(&buf, wrp.Msgpack).Encode(&wrp.Message{
+			Type:            wrp.SimpleRequestResponseMessageType,
+			Source:          "dns:talaria",
+			Destination:     clientConfig.DeviceName + "/slow",
+			TransactionUUID: "123",
+		})
+		if err = conn.WriteMessage(websocket.BinaryMessage, buf.Bytes()); err != nil {
+			return
+		}
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg wrp.Message
+			if wrp.NewDecoderBytes(data, wrp.Msgpack).Decode(&msg) == nil && msg.TransactionUUID == "123" {
+				responses <- &msg
+			}
+		}
+	}))
+	defer server.Close()
+
+	started := make(chan struct{})
+	config := clientConfig
+	config.DestinationURL = server.URL
+	config.Handlers = []HandlerConfig{{
+		Regexp: "/slow",
+		Handler: HandlerFunc(func(msg *wrp.Message) *wrp.Message {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			return ReplyWithStatus(http.StatusOK)(msg)
+		}),
+	}}
+	testClient, err := NewClient(config)
+	require.NoError(err)
+
+	<-started
+	require.NoError(testClient.Close())
+	select {
+	case response := <-responses:
+		assert.Equal(int64(http.StatusOK), *response.Status)
+	case <-time.After(5 * time.Second):
+		require.Fail("the response was not written before the client closed")
+	}
+}
+
 // test the happy path of receiving a message from the server via websocket
 // users will never make function calls to this, in the normal use case
 // they simply provide a handler and let a go routine deal with this call
@@ -390,24 +464,22 @@ func TestRead(t *testing.T) {
 	})
 	require.NoError(err)
 	logger := sallust.Default()
-	sender := NewSender(fakeConn, 1, 1, logger)
-	encoder := NewEncoderSender(sender, 1, 1, logger)
+	sender := NewSender(fakeConn, QueueConfig{MaxWorkers: 1, Size: 1}, WriteConfig{}, nil, logger)
+	encoder := NewEncoderSender(sender, QueueConfig{MaxWorkers: 1, Size: 1}, nil, logger)
 
 	rh := NewRegistryHandler(func(message *wrp.Message) {},
 		registry,
-		NewDownstreamSender(func(message *wrp.Message) {}, 1, 1, logger),
-		1, 1, clientConfig.DeviceName, logger)
-	decoder := NewDecoderSender(rh, 1, 1, logger)
+		NewDownstreamSender(func(message *wrp.Message) {}, QueueConfig{MaxWorkers: 1, Size: 1}, nil, logger),
+		QueueConfig{MaxWorkers: 1, Size: 1}, clientConfig.DeviceName, nil, logger)
+	decoder := NewDecoderSender(rh, QueueConfig{MaxWorkers: 1, Size: 1}, nil, logger)
 	testClient := &client{
-		deviceID:        clientConfig.DeviceName,
-		userAgent:       "",
-		deviceProtocols: "",
-		registry:        registry,
-		connection:      fakeConn,
-		encoderSender:   encoder,
-		decoderSender:   decoder,
-		headerInfo:      nil,
-		logger:          sallust.Default(),
+		deviceID:      clientConfig.DeviceName,
+		registry:      registry,
+		connection:    newManagedConnection(fakeConn),
+		encoderSender: encoder,
+		decoderSender: decoder,
+		headerInfo:    nil,
+		logger:        sallust.Default(),
 	}
 
 	mainWG.Add(1)
@@ -420,3 +492,24 @@ func TestRead(t *testing.T) {
 	assert.Nil(err)
 	fakeConn.AssertExpectations(t)
 }
+
+func TestHostnameFromURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{url: "ws://talaria:6200/api/v2/device", expected: "talaria"},
+		{url: "wss://talaria:6200/api/v2/device", expected: "talaria"},
+		{url: "wss://talaria/api/v2/device", expected: "talaria"},
+		{url: "ws://[::1]:6200/api/v2/device", expected: "::1"},
+		{url: "wss://[fe80::1]/api/v2/device", expected: "fe80::1"},
+		{url: "ws://talaria/api/v2/device/mac:112233445566", expected: "talaria"},
+		{url: "ws://talaria:6200/path?with=a:colon", expected: "talaria"},
+		{url: "://", expected: ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.url, func(t *testing.T) {
+			assert.Equal(t, tc.expected, hostnameFromURL(tc.url))
+		})
+	}
+}
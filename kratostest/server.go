@@ -0,0 +1,422 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package kratostest provides an in-process fake Talaria for testing code that
+// uses kratos clients.
+package kratostest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/xmidt-org/kratos"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+const (
+	// Time allowed to write a message to a device.
+	writeWait = 10 * time.Second
+)
+
+var (
+	// ErrDeviceNotConnected is returned when a device being sent to is not
+	// connected to the Server.
+	ErrDeviceNotConnected = errors.New("device is not connected")
+)
+
+// DeviceHeaders are the headers every device must connect with.
+var DeviceHeaders = []string{
+	kratos.DeviceNameHeader,
+	kratos.FirmwareNameHeader,
+	kratos.ModelNameHeader,
+	kratos.ManufacturerHeader,
+}
+
+// HandlerFunc is called with every message a device sends.  A non-nil
+// response is sent back to the device.
+type HandlerFunc func(deviceID wrp.DeviceID, msg *wrp.Message) *wrp.Message
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithPingInterval makes the Server ping every connected device on the given
+// interval.
+func WithPingInterval(interval time.Duration) Option {
+	return func(s *Server) {
+		s.pingInterval = interval
+	}
+}
+
+// WithHandler sets the function called with every message a device sends.
+func WithHandler(handler HandlerFunc) Option {
+	return func(s *Server) {
+		s.handler = handler
+	}
+}
+
+// WithTLS makes the Server listen with TLS, using a self signed certificate.
+// Use Certificate to trust it.
+func WithTLS() Option {
+	return func(s *Server) {
+		s.tls = true
+	}
+}
+
+// response is a canned response for the next connection attempt.
+type response struct {
+	statusCode int
+	location   string
+}
+
+// device is a device connected to the Server.
+type device struct {
+	id      wrp.DeviceID
+	conn    *websocket.Conn
+	headers http.Header
+	lock    sync.Mutex
+}
+
+// write sends a websocket message to the device.
+func (d *device) write(messageType int, data []byte) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return d.conn.WriteMessage(messageType, data)
+}
+
+// Server is a fake Talaria.  It validates the headers devices connect with,
+// records the messages they send, and lets tests push messages to devices,
+// ping them, and simulate redirects, errors and disconnects.
+type Server struct {
+	server       *httptest.Server
+	upgrader     websocket.Upgrader
+	pingInterval time.Duration
+	handler      HandlerFunc
+	tls          bool
+
+	lock      sync.Mutex
+	devices   map[wrp.DeviceID]*device
+	received  []*wrp.Message
+	responses []response
+	changed   chan struct{}
+	closed    bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewServer creates and starts a Server.  Devices should connect to URL.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		devices: make(map[wrp.DeviceID]*device),
+		changed: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+
+	s.server = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+	if s.tls {
+		s.server.StartTLS()
+	} else {
+		s.server.Start()
+	}
+
+	if s.pingInterval > 0 {
+		s.wg.Add(1)
+		go s.pingDevices()
+	}
+	return s
+}
+
+// URL is the address devices should connect to.
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Certificate returns the certificate used by a Server started with WithTLS.
+func (s *Server) Certificate() []byte {
+	if cert := s.server.Certificate(); cert != nil {
+		return cert.Raw
+	}
+	return nil
+}
+
+// Close disconnects all devices and shuts down the Server.
+func (s *Server) Close() {
+	close(s.done)
+	s.lock.Lock()
+	// once closed, no more readers are added to the WaitGroup.
+	s.closed = true
+	for _, d := range s.devices {
+		d.conn.Close()
+	}
+	s.lock.Unlock()
+	s.wg.Wait()
+	s.server.CloseClientConnections()
+	s.server.Close()
+}
+
+// RedirectNext makes the next connection attempt receive a 307 redirect to
+// the location given.
+func (s *Server) RedirectNext(location string) {
+	s.lock.Lock()
+	s.responses = append(s.responses, response{statusCode: http.StatusTemporaryRedirect, location: location})
+	s.lock.Unlock()
+}
+
+// FailNext makes the next connection attempt fail with the status code given,
+// such as kratos.StatusDeviceDisconnected or kratos.StatusDeviceTimeout.
+func (s *Server) FailNext(statusCode int) {
+	s.lock.Lock()
+	s.responses = append(s.responses, response{statusCode: statusCode})
+	s.lock.Unlock()
+}
+
+// Devices lists the devices currently connected.
+func (s *Server) Devices() []wrp.DeviceID {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ids := make([]wrp.DeviceID, 0, len(s.devices))
+	for id := range s.devices {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Headers returns the headers the device connected with.
+func (s *Server) Headers(deviceID wrp.DeviceID) (http.Header, error) {
+	d, err := s.device(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	return d.headers.Clone(), nil
+}
+
+// Received returns every message received from devices so far.
+func (s *Server) Received() []*wrp.Message {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*wrp.Message{}, s.received...)
+}
+
+// WaitForDevice blocks until the device is connected or the context is done.
+func (s *Server) WaitForDevice(ctx context.Context, deviceID wrp.DeviceID) error {
+	return s.wait(ctx, func() bool {
+		_, ok := s.devices[deviceID]
+		return ok
+	})
+}
+
+// WaitForMessage blocks until a message matching the function is received or
+// the context is done.  Messages received before WaitForMessage is called are
+// also considered.
+func (s *Server) WaitForMessage(ctx context.Context, match func(*wrp.Message) bool) (*wrp.Message, error) {
+	var found *wrp.Message
+	err := s.wait(ctx, func() bool {
+		for _, msg := range s.received {
+			if match(msg) {
+				found = msg
+				return true
+			}
+		}
+		return false
+	})
+	return found, err
+}
+
+// Send encodes the message and sends it to the device.
+func (s *Server) Send(deviceID wrp.DeviceID, msg *wrp.Message) error {
+	d, err := s.device(deviceID)
+	if err != nil {
+		return err
+	}
+	var buffer bytes.Buffer
+	if err = wrp.NewEncoder(&buffer, wrp.Msgpack).Encode(msg); err != nil {
+		return err
+	}
+	return d.write(websocket.BinaryMessage, buffer.Bytes())
+}
+
+// Ping sends a ping to the device.
+func (s *Server) Ping(deviceID wrp.DeviceID) error {
+	d, err := s.device(deviceID)
+	if err != nil {
+		return err
+	}
+	return d.write(websocket.PingMessage, []byte(deviceID))
+}
+
+// Disconnect abruptly drops the device's connection, without a close
+// handshake.
+func (s *Server) Disconnect(deviceID wrp.DeviceID) error {
+	d, err := s.device(deviceID)
+	if err != nil {
+		return err
+	}
+	return d.conn.NetConn().Close()
+}
+
+// device gets a connected device.
+func (s *Server) device(deviceID wrp.DeviceID) (*device, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	d, ok := s.devices[deviceID]
+	if !ok {
+		return nil, ErrDeviceNotConnected
+	}
+	return d, nil
+}
+
+// wait blocks until the condition is true or the context is done.  The
+// condition is checked with the lock held.
+func (s *Server) wait(ctx context.Context, condition func() bool) error {
+	for {
+		s.lock.Lock()
+		ok := condition()
+		changed := s.changed
+		s.lock.Unlock()
+		if ok {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// notify wakes anyone waiting on the Server.  The lock must be held.
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// serveHTTP validates the device's headers and upgrades the connection.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		writeError(w, http.StatusServiceUnavailable, "server closed")
+		return
+	}
+	if len(s.responses) > 0 {
+		next := s.responses[0]
+		s.responses = s.responses[1:]
+		s.lock.Unlock()
+		writeResponse(w, r, next)
+		return
+	}
+	s.lock.Unlock()
+
+	for _, h := range DeviceHeaders {
+		if len(r.Header.Values(h)) == 0 {
+			writeError(w, http.StatusBadRequest, "missing header "+h)
+			return
+		}
+	}
+	deviceID, err := wrp.ParseDeviceID(r.Header.Get(kratos.DeviceNameHeader))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	d := &device{id: deviceID, conn: conn, headers: r.Header.Clone()}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		conn.Close()
+		return
+	}
+	if old, ok := s.devices[deviceID]; ok {
+		old.conn.Close()
+	}
+	s.devices[deviceID] = d
+	s.notify()
+
+	s.wg.Add(1)
+	go s.read(d)
+}
+
+// read records every message sent by the device until it disconnects.
+func (s *Server) read(d *device) {
+	defer s.wg.Done()
+	defer func() {
+		s.lock.Lock()
+		if s.devices[d.id] == d {
+			delete(s.devices, d.id)
+			s.notify()
+		}
+		s.lock.Unlock()
+		d.conn.Close()
+	}()
+
+	for {
+		_, data, err := d.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg wrp.Message
+		if err = wrp.NewDecoderBytes(data, wrp.Msgpack).Decode(&msg); err != nil {
+			continue
+		}
+
+		s.lock.Lock()
+		s.received = append(s.received, &msg)
+		s.notify()
+		s.lock.Unlock()
+
+		if s.handler != nil {
+			if response := s.handler(d.id, &msg); response != nil {
+				s.Send(d.id, response)
+			}
+		}
+	}
+}
+
+// pingDevices pings every connected device on the ping interval.
+func (s *Server) pingDevices() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			for _, id := range s.Devices() {
+				s.Ping(id)
+			}
+		}
+	}
+}
+
+// writeResponse writes a canned response.
+func writeResponse(w http.ResponseWriter, r *http.Request, resp response) {
+	if resp.location != "" {
+		http.Redirect(w, r, resp.location, resp.statusCode)
+		return
+	}
+	writeError(w, resp.statusCode, http.StatusText(resp.statusCode))
+}
+
+// writeError writes an error in the same format as Talaria.
+func writeError(w http.ResponseWriter, statusCode int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(kratos.Message{Code: statusCode, Body: body})
+}
@@ -0,0 +1,210 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratostest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/kratos"
+	"github.com/xmidt-org/kratos/kratostest"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+const deviceID = wrp.DeviceID("mac:112233445566")
+
+// channelHandler passes every message it handles to a channel.
+type channelHandler chan *wrp.Message
+
+func (c channelHandler) HandleMessage(msg *wrp.Message) *wrp.Message {
+	c <- msg
+	return nil
+}
+
+func (c channelHandler) Close() {}
+
+func newConfig(url string) kratos.ClientConfig {
+	return kratos.ClientConfig{
+		DeviceName:     string(deviceID),
+		FirmwareName:   "firmware",
+		ModelName:      "model",
+		Manufacturer:   "manufacturer",
+		DestinationURL: url,
+		HandlePingMiss: func() error { return nil },
+	}
+}
+
+func newContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestServerMessages(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server := kratostest.NewServer()
+	defer server.Close()
+
+	handled := make(channelHandler, 1)
+	config := newConfig(server.URL())
+	config.Handlers = []kratos.HandlerConfig{{Regexp: "/config", Handler: handled}}
+	client, err := kratos.NewClient(config)
+	require.NoError(err)
+	defer client.Close()
+
+	ctx := newContext(t)
+	require.NoError(server.WaitForDevice(ctx, deviceID))
+	headers, err := server.Headers(deviceID)
+	require.NoError(err)
+	assert.Equal("model", headers.Get("X-Webpa-Model-Name"))
+
+	// upstream
+	client.Send(&wrp.Message{
+		Type:        wrp.SimpleEventMessageType,
+		Source:      string(deviceID) + "/emu",
+		Destination: "event:device-status/online",
+	})
+	msg, err := server.WaitForMessage(ctx, func(m *wrp.Message) bool {
+		return m.Destination == "event:device-status/online"
+	})
+	require.NoError(err)
+	assert.Equal(wrp.SimpleEventMessageType, msg.Type)
+	assert.Len(server.Received(), 1)
+
+	// downstream
+	require.NoError(server.Send(deviceID, &wrp.Message{
+		Type:        wrp.SimpleEventMessageType,
+		Source:      "dns:talaria",
+		Destination: string(deviceID) + "/config",
+	}))
+	select {
+	case msg = <-handled:
+		assert.Equal("dns:talaria", msg.Source)
+	case <-ctx.Done():
+		t.Fatal("message was never handled")
+	}
+
+	assert.ErrorIs(server.Send("mac:000000000000", &wrp.Message{}), kratostest.ErrDeviceNotConnected)
+}
+
+func TestServerHandler(t *testing.T) {
+	server := kratostest.NewServer(kratostest.WithHandler(func(_ wrp.DeviceID, msg *wrp.Message) *wrp.Message {
+		response := *msg
+		response.Source, response.Destination = msg.Destination, msg.Source
+		response.Payload = []byte("pong")
+		return &response
+	}))
+	defer server.Close()
+
+	client, err := kratos.NewClient(newConfig(server.URL()))
+	require.NoError(t, err)
+	defer client.Close()
+
+	response, err := client.Request(newContext(t), &wrp.Message{
+		Type:            wrp.SimpleRequestResponseMessageType,
+		Source:          string(deviceID) + "/emu",
+		Destination:     "dns:talaria/ping",
+		TransactionUUID: "ping",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("pong"), response.Payload)
+}
+
+func TestServerFailures(t *testing.T) {
+	assert := assert.New(t)
+
+	server := kratostest.NewServer()
+	defer server.Close()
+
+	server.FailNext(kratos.StatusDeviceDisconnected)
+	_, err := kratos.NewClient(newConfig(server.URL()))
+	var coder kratos.StatusCoder
+	assert.True(errors.As(err, &coder))
+	assert.Equal(kratos.StatusDeviceDisconnected, coder.StatusCode())
+
+	config := newConfig(server.URL())
+	config.DeviceName = "not-a-device"
+	_, err = kratos.NewClient(config)
+	assert.Error(err)
+
+	server.RedirectNext(server.URL() + "/api/v2/device")
+	var redirects atomic.Int32
+	config = newConfig(server.URL())
+	config.Listeners = []kratos.ClientListener{kratos.ClientListenerFuncs{
+		Redirect: func(e kratos.ConnectionEvent) {
+			redirects.Add(1)
+			assert.Equal(http.StatusTemporaryRedirect, e.StatusCode)
+		},
+	}}
+	client, err := kratos.NewClient(config)
+	assert.NoError(err)
+	assert.Equal(int32(1), redirects.Load())
+	client.Close()
+}
+
+func TestServerDisconnect(t *testing.T) {
+	require := require.New(t)
+
+	server := kratostest.NewServer(kratostest.WithPingInterval(10 * time.Millisecond))
+	defer server.Close()
+
+	var connects atomic.Int32
+	config := newConfig(server.URL())
+	config.PingConfig = kratos.PingConfig{PingWait: time.Second}
+	config.HandlePingMiss = func() error {
+		t.Error("ping should not be missed")
+		return nil
+	}
+	config.Reconnect = kratos.ReconnectConfig{Enabled: true, InitialInterval: 10 * time.Millisecond}
+	config.Listeners = []kratos.ClientListener{kratos.ClientListenerFuncs{
+		Connect: func(kratos.ConnectionEvent) { connects.Add(1) },
+	}}
+	client, err := kratos.NewClient(config)
+	require.NoError(err)
+	defer client.Close()
+
+	ctx := newContext(t)
+	require.NoError(server.WaitForDevice(ctx, deviceID))
+	require.NoError(server.Disconnect(deviceID))
+	require.Eventually(func() bool {
+		return connects.Load() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(server.WaitForDevice(ctx, deviceID))
+}
+
+func TestServerCloseWhileConnecting(t *testing.T) {
+	server := kratostest.NewServer()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			config := newConfig(server.URL())
+			config.DeviceName = fmt.Sprintf("mac:1122334455%02x", i)
+			for {
+				client, err := kratos.NewClient(config)
+				if err != nil {
+					return
+				}
+				client.Close()
+			}
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	server.Close()
+	wg.Wait()
+	assert.Empty(t, server.Devices())
+}
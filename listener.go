@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"errors"
+	"time"
+)
+
+// ConnectionEvent describes a change to the client's connection to XMiDT.
+type ConnectionEvent struct {
+	// URL is the websocket URL the event is about.
+	URL string
+
+	// Location is where the client is being redirected to, resolved against
+	// URL.  It is only set for redirects.
+	Location string
+
+	// StatusCode is the HTTP status code received when dialing, if any.
+	StatusCode int
+
+	// Err is the error that caused the event, if any.
+	Err error
+
+	// Attempt is the reconnect attempt the event is part of.  It is zero for
+	// the initial connection.
+	Attempt int
+
+	// Time is when the event happened.
+	Time time.Time
+}
+
+// ClientListener is notified about the lifecycle of the client's connection.
+// The functions are called synchronously, so they should not block.
+type ClientListener interface {
+	// OnConnect is called every time a connection is established, including
+	// after a reconnect.
+	OnConnect(ConnectionEvent)
+
+	// OnDisconnect is called when the connection is lost or the client is
+	// closed.  When the client is closed, Err is ErrClientClosed.
+	OnDisconnect(ConnectionEvent)
+
+	// OnReconnect is called after every reconnect attempt, successful or not.
+	OnReconnect(ConnectionEvent)
+
+	// OnRedirect is called every time dialing is redirected.
+	OnRedirect(ConnectionEvent)
+}
+
+// ClientListenerFuncs is a ClientListener made of optional functions.
+type ClientListenerFuncs struct {
+	Connect    func(ConnectionEvent)
+	Disconnect func(ConnectionEvent)
+	Reconnect  func(ConnectionEvent)
+	Redirect   func(ConnectionEvent)
+}
+
+// OnConnect calls Connect, if set.
+func (f ClientListenerFuncs) OnConnect(e ConnectionEvent) {
+	if f.Connect != nil {
+		f.Connect(e)
+	}
+}
+
+// OnDisconnect calls Disconnect, if set.
+func (f ClientListenerFuncs) OnDisconnect(e ConnectionEvent) {
+	if f.Disconnect != nil {
+		f.Disconnect(e)
+	}
+}
+
+// OnReconnect calls Reconnect, if set.
+func (f ClientListenerFuncs) OnReconnect(e ConnectionEvent) {
+	if f.Reconnect != nil {
+		f.Reconnect(e)
+	}
+}
+
+// OnRedirect calls Redirect, if set.
+func (f ClientListenerFuncs) OnRedirect(e ConnectionEvent) {
+	if f.Redirect != nil {
+		f.Redirect(e)
+	}
+}
+
+// listeners lets the client notify all of its ClientListeners at once.
+type listeners []ClientListener
+
+// newConnectionEvent creates a ConnectionEvent, pulling the status code out
+// of the error when there is one.
+func newConnectionEvent(url string, attempt int, err error) ConnectionEvent {
+	e := ConnectionEvent{
+		URL:     url,
+		Err:     err,
+		Attempt: attempt,
+		Time:    time.Now(),
+	}
+	var coder StatusCoder
+	if errors.As(err, &coder) {
+		e.StatusCode = coder.StatusCode()
+	}
+	return e
+}
+
+func (l listeners) onConnect(e ConnectionEvent) {
+	for _, listener := range l {
+		listener.OnConnect(e)
+	}
+}
+
+func (l listeners) onDisconnect(e ConnectionEvent) {
+	for _, listener := range l {
+		listener.OnDisconnect(e)
+	}
+}
+
+func (l listeners) onReconnect(e ConnectionEvent) {
+	for _, listener := range l {
+		listener.OnReconnect(e)
+	}
+}
+
+func (l listeners) onRedirect(e ConnectionEvent) {
+	for _, listener := range l {
+		listener.OnRedirect(e)
+	}
+}
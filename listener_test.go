@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingListener keeps every event it is given, by kind.
+type recordingListener struct {
+	lock   sync.Mutex
+	events map[string][]ConnectionEvent
+}
+
+func newRecordingListener() (*recordingListener, ClientListener) {
+	r := &recordingListener{events: make(map[string][]ConnectionEvent)}
+	record := func(kind string) func(ConnectionEvent) {
+		return func(e ConnectionEvent) {
+			r.lock.Lock()
+			r.events[kind] = append(r.events[kind], e)
+			r.lock.Unlock()
+		}
+	}
+	return r, ClientListenerFuncs{
+		Connect:    record("connect"),
+		Disconnect: record("disconnect"),
+		Reconnect:  record("reconnect"),
+		Redirect:   record("redirect"),
+	}
+}
+
+func (r *recordingListener) get(kind string) []ConnectionEvent {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]ConnectionEvent{}, r.events[kind]...)
+}
+
+func TestListenerRedirectAndClose(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, testServer.URL+"/api/v2/device", http.StatusTemporaryRedirect)
+	}))
+	defer redirector.Close()
+
+	recorder, listener := newRecordingListener()
+	config := clientConfig
+	config.DestinationURL = redirector.URL
+	config.Listeners = []ClientListener{listener}
+	testClient, err := NewClient(config)
+	require.NoError(err)
+
+	redirects := recorder.get("redirect")
+	require.Len(redirects, 1)
+	assert.Equal(http.StatusTemporaryRedirect, redirects[0].StatusCode)
+	assert.Equal(testServer.URL+"/api/v2/device", redirects[0].Location)
+
+	connects := recorder.get("connect")
+	require.Len(connects, 1)
+	assert.Equal(0, connects[0].Attempt)
+
+	require.NoError(testClient.Close())
+	disconnects := recorder.get("disconnect")
+	require.Len(disconnects, 1)
+	assert.ErrorIs(disconnects[0].Err, ErrClientClosed)
+}
+
+func TestListenerReconnect(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var lock sync.Mutex
+	connects := 0
+	// hold on to the connections, which would otherwise be closed when
+	// they're garbage collected.
+	var conns []*websocket.Conn
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		connects++
+		count := connects
+		lock.Unlock()
+		switch count {
+		case 1:
+			// drop the first connection.
+			if conn, err := upgrader.Upgrade(w, r, nil); err == nil {
+				conn.Close()
+			}
+		case 2:
+			// fail the first reconnect attempt.
+			w.WriteHeader(StatusDeviceDisconnected)
+		default:
+			if conn, err := upgrader.Upgrade(w, r, nil); err == nil {
+				lock.Lock()
+				conns = append(conns, conn)
+				lock.Unlock()
+			}
+		}
+	}))
+	defer server.Close()
+
+	recorder, listener := newRecordingListener()
+	config := clientConfig
+	config.DestinationURL = server.URL
+	config.Listeners = []ClientListener{listener}
+	config.Reconnect = ReconnectConfig{
+		Enabled:         true,
+		InitialInterval: 10 * time.Millisecond,
+	}
+	testClient, err := NewClient(config)
+	require.NoError(err)
+	defer testClient.Close()
+
+	assert.Eventually(func() bool {
+		return len(recorder.get("connect")) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	disconnects := recorder.get("disconnect")
+	require.Len(disconnects, 1)
+	assert.Error(disconnects[0].Err)
+
+	reconnects := recorder.get("reconnect")
+	require.Len(reconnects, 2)
+	assert.Equal(1, reconnects[0].Attempt)
+	assert.Equal(StatusDeviceDisconnected, reconnects[0].StatusCode)
+	assert.Equal(2, reconnects[1].Attempt)
+	assert.NoError(reconnects[1].Err)
+}
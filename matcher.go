@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+// Matcher decides whether a message should be routed to a handler.  A
+// handler's matchers are checked along with its regular expression, and all
+// of them must match.
+type Matcher func(msg *wrp.Message) bool
+
+// LocatorMatch matches the parts of a WRP locator, such as
+// mac:112233445566/config/ignored, where mac is the scheme, 112233445566 the
+// authority, config the service and /ignored the ignored path.  Empty fields
+// match anything.  Scheme and Authority are compared without regard to case,
+// and Ignore matches ignored paths that start with it.
+type LocatorMatch struct {
+	Scheme    string `yaml:"scheme"`
+	Authority string `yaml:"authority"`
+	Service   string `yaml:"service"`
+	Ignore    string `yaml:"ignore"`
+}
+
+// matches checks the locator.  Locators that can't be parsed never match.
+func (l LocatorMatch) matches(locator string) bool {
+	parsed, err := wrp.ParseLocator(locator)
+	if err != nil {
+		return false
+	}
+	return (l.Scheme == "" || strings.EqualFold(l.Scheme, parsed.Scheme)) &&
+		(l.Authority == "" || strings.EqualFold(l.Authority, parsed.Authority)) &&
+		(l.Service == "" || l.Service == parsed.Service) &&
+		strings.HasPrefix(parsed.Ignored, l.Ignore)
+}
+
+// MatchTypes matches messages of any of the types given.
+func MatchTypes(types ...wrp.MessageType) Matcher {
+	return func(msg *wrp.Message) bool {
+		return slices.Contains(types, msg.Type)
+	}
+}
+
+// MatchDestination matches messages with destinations that match the
+// LocatorMatch.
+func MatchDestination(l LocatorMatch) Matcher {
+	return func(msg *wrp.Message) bool {
+		return l.matches(msg.Destination)
+	}
+}
+
+// MatchSource matches messages with sources that match the LocatorMatch.
+func MatchSource(l LocatorMatch) Matcher {
+	return func(msg *wrp.Message) bool {
+		return l.matches(msg.Source)
+	}
+}
+
+// MatchRegexp matches messages with destinations that match the regular
+// expression, like a HandlerConfig's Regexp.
+func MatchRegexp(r *regexp.Regexp) Matcher {
+	return func(msg *wrp.Message) bool {
+		return r.MatchString(msg.Destination)
+	}
+}
+
+// MatchAll matches messages that all of the matchers match.
+func MatchAll(matchers ...Matcher) Matcher {
+	return func(msg *wrp.Message) bool {
+		for _, m := range matchers {
+			if !m(msg) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// MatchAny matches messages that any of the matchers match.
+func MatchAny(matchers ...Matcher) Matcher {
+	return func(msg *wrp.Message) bool {
+		for _, m := range matchers {
+			if m(msg) {
+				return true
+			}
+		}
+		return false
+	}
+}
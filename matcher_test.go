@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+func TestMatchers(t *testing.T) {
+	msg := &wrp.Message{
+		Type:        wrp.UpdateMessageType,
+		Source:      "dns:talaria.example.com/api",
+		Destination: "mac:112233445566/config/wifi/ssid",
+	}
+	tests := []struct {
+		description string
+		matcher     Matcher
+		expected    bool
+	}{
+		{"type", MatchTypes(wrp.RetrieveMessageType, wrp.UpdateMessageType), true},
+		{"other type", MatchTypes(wrp.SimpleEventMessageType), false},
+		{"empty locator", MatchDestination(LocatorMatch{}), true},
+		{"scheme", MatchDestination(LocatorMatch{Scheme: "MAC"}), true},
+		{"authority", MatchDestination(LocatorMatch{Scheme: "mac", Authority: "112233445566"}), true},
+		{"other authority", MatchDestination(LocatorMatch{Authority: "665544332211"}), false},
+		{"service", MatchDestination(LocatorMatch{Service: "config"}), true},
+		{"other service", MatchDestination(LocatorMatch{Service: "wifi"}), false},
+		{"ignore", MatchDestination(LocatorMatch{Service: "config", Ignore: "/wifi"}), true},
+		{"other ignore", MatchDestination(LocatorMatch{Ignore: "/ssid"}), false},
+		{"source", MatchSource(LocatorMatch{Scheme: "dns", Authority: "talaria.example.com", Service: "api"}), true},
+		{"other source", MatchSource(LocatorMatch{Scheme: "mac"}), false},
+		{"regexp", MatchRegexp(regexp.MustCompile("/config/")), true},
+		{"all", MatchAll(MatchTypes(wrp.UpdateMessageType), MatchSource(LocatorMatch{Scheme: "dns"})), true},
+		{"not all", MatchAll(MatchTypes(wrp.UpdateMessageType), MatchSource(LocatorMatch{Scheme: "mac"})), false},
+		{"any", MatchAny(MatchTypes(wrp.SimpleEventMessageType), MatchSource(LocatorMatch{Scheme: "dns"})), true},
+		{"none", MatchAny(), false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.matcher(msg))
+		})
+	}
+
+	assert.False(t, MatchDestination(LocatorMatch{})(&wrp.Message{Destination: "not a locator"}))
+}
+
+func TestHandlerRegistryMatch(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	events, crud, fallback := &echoHandler{}, &echoHandler{}, &echoHandler{}
+	registry, err := NewHandlerRegistry([]HandlerConfig{
+		{Name: "events", Match: []Matcher{MatchTypes(wrp.SimpleEventMessageType)}, Handler: events},
+		{
+			Name:    "crud",
+			Regexp:  "/config",
+			Match:   []Matcher{MatchTypes(wrp.CreateMessageType, wrp.RetrieveMessageType, wrp.UpdateMessageType, wrp.DeleteMessageType)},
+			Handler: crud,
+		},
+		{Regexp: ".*", Priority: -1, Handler: fallback},
+	})
+	require.NoError(err)
+
+	handler, err := registry.HandlerFor(&wrp.Message{Type: wrp.SimpleEventMessageType, Destination: "event:online"})
+	require.NoError(err)
+	assert.Same(events, handler)
+
+	handler, err = registry.HandlerFor(&wrp.Message{Type: wrp.RetrieveMessageType, Destination: "mac:112233445566/config"})
+	require.NoError(err)
+	assert.Same(crud, handler)
+
+	// the regular expression still has to match.
+	handler, err = registry.HandlerFor(&wrp.Message{Type: wrp.RetrieveMessageType, Destination: "mac:112233445566/other"})
+	require.NoError(err)
+	assert.Same(fallback, handler)
+
+	// handlers that need more than the destination are skipped.
+	handler, err = registry.GetHandler("mac:112233445566/config")
+	require.NoError(err)
+	assert.Same(fallback, handler)
+
+	handlers := registry.Handlers()
+	require.Len(handlers, 3)
+	assert.Equal("events", handlers[0].Name)
+	assert.Equal("", handlers[0].Regexp)
+	assert.Len(handlers[0].Match, 1)
+
+	registry.Remove("events")
+	handler, err = registry.HandlerFor(&wrp.Message{Type: wrp.SimpleEventMessageType, Destination: "event:online"})
+	require.NoError(err)
+	assert.Same(fallback, handler)
+}
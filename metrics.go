@@ -0,0 +1,243 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+const (
+	metricsNamespace = "kratos"
+
+	stageLabel     = "stage"
+	directionLabel = "direction"
+	typeLabel      = "type"
+	outcomeLabel   = "outcome"
+
+	inboundDirection  = "in"
+	outboundDirection = "out"
+
+	successOutcome = "success"
+	failureOutcome = "failure"
+)
+
+// Metrics are the prometheus metrics recorded by a client.  The same Metrics
+// can be shared by many clients, in which case the values are totals across
+// all of them.  A nil *Metrics records nothing.
+type Metrics struct {
+	queueDepth      *prometheus.GaugeVec
+	busyWorkers     *prometheus.GaugeVec
+	dropped         *prometheus.CounterVec
+	messages        *prometheus.CounterVec
+	encodeFailures  prometheus.Counter
+	decodeFailures  prometheus.Counter
+	handlerDuration *prometheus.HistogramVec
+	handlerErrors   prometheus.Counter
+	handlerPanics   prometheus.Counter
+	handlerTimeouts prometheus.Counter
+	pingMisses      prometheus.Counter
+	reconnects      *prometheus.CounterVec
+}
+
+// NewMetrics creates the client metrics and registers them with the
+// registerer.  Use prometheus.WrapRegistererWith to add labels, such as a
+// fleet name, to every metric.
+func NewMetrics(registerer prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		queueDepth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "queue_depth",
+			Help:      "The number of messages waiting in a queue.",
+		}, []string{stageLabel}),
+		busyWorkers: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "busy_workers",
+			Help:      "The number of workers currently processing a message.",
+		}, []string{stageLabel}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "dropped_messages_total",
+			Help:      "The number of messages dropped or rejected by a full queue.",
+		}, []string{stageLabel}),
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "messages_total",
+			Help:      "The number of WRP messages received from or sent to XMiDT.",
+		}, []string{directionLabel, typeLabel}),
+		encodeFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "encode_failures_total",
+			Help:      "The number of WRP messages that failed to encode.",
+		}),
+		decodeFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "decode_failures_total",
+			Help:      "The number of WRP messages that failed to decode.",
+		}),
+		handlerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "handler_duration_seconds",
+			Help:      "How long downstream handlers take to handle a message.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{typeLabel}),
+		handlerErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "handler_errors_total",
+			Help:      "The number of messages downstream handlers failed to handle, including panics and timeouts.",
+		}),
+		handlerPanics: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "handler_panics_total",
+			Help:      "The number of panics recovered from downstream handlers.",
+		}),
+		handlerTimeouts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "handler_timeouts_total",
+			Help:      "The number of messages downstream handlers took too long to handle.",
+		}),
+		pingMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "ping_misses_total",
+			Help:      "The number of pings missed.",
+		}),
+		reconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "reconnect_attempts_total",
+			Help:      "The number of attempts to reconnect to XMiDT.",
+		}, []string{outcomeLabel}),
+	}
+
+	collectors := []prometheus.Collector{
+		m.queueDepth, m.busyWorkers, m.dropped, m.messages, m.encodeFailures,
+		m.decodeFailures, m.handlerDuration, m.handlerErrors, m.handlerPanics,
+		m.handlerTimeouts, m.pingMisses, m.reconnects,
+	}
+	for _, c := range collectors {
+		if err := registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// queued records a message being added to a queue.
+func (m *Metrics) queued(stage Stage) {
+	if m != nil {
+		m.queueDepth.WithLabelValues(string(stage)).Inc()
+	}
+}
+
+// dequeued records a message being taken off of a queue.
+func (m *Metrics) dequeued(stage Stage) {
+	if m != nil {
+		m.queueDepth.WithLabelValues(string(stage)).Dec()
+	}
+}
+
+// enqueueResult records the outcome of adding a message to a queue.
+func (m *Metrics) enqueueResult(stage Stage, err error) {
+	if m == nil {
+		return
+	}
+	switch {
+	case err == nil:
+		m.queued(stage)
+	case errors.Is(err, ErrMessageDropped), errors.Is(err, ErrQueueFull):
+		m.dropped.WithLabelValues(string(stage)).Inc()
+	}
+}
+
+// droppedOldest records the oldest message in a queue being dropped.
+func (m *Metrics) droppedOldest(stage Stage) {
+	if m != nil {
+		m.dequeued(stage)
+		m.dropped.WithLabelValues(string(stage)).Inc()
+	}
+}
+
+// workerStarted records a worker starting on a message.
+func (m *Metrics) workerStarted(stage Stage) {
+	if m != nil {
+		m.busyWorkers.WithLabelValues(string(stage)).Inc()
+	}
+}
+
+// workerDone records a worker finishing a message.
+func (m *Metrics) workerDone(stage Stage) {
+	if m != nil {
+		m.busyWorkers.WithLabelValues(string(stage)).Dec()
+	}
+}
+
+// message records a WRP message going in or out.
+func (m *Metrics) message(direction string, msgType wrp.MessageType) {
+	if m != nil {
+		m.messages.WithLabelValues(direction, msgType.FriendlyName()).Inc()
+	}
+}
+
+// encodeFailure records a message that could not be encoded.
+func (m *Metrics) encodeFailure() {
+	if m != nil {
+		m.encodeFailures.Inc()
+	}
+}
+
+// decodeFailure records a message that could not be decoded.
+func (m *Metrics) decodeFailure() {
+	if m != nil {
+		m.decodeFailures.Inc()
+	}
+}
+
+// handled records how long a handler took with a message.
+func (m *Metrics) handled(msgType wrp.MessageType, d time.Duration) {
+	if m != nil {
+		m.handlerDuration.WithLabelValues(msgType.FriendlyName()).Observe(d.Seconds())
+	}
+}
+
+// handlerError records a handler failing to handle a message.
+func (m *Metrics) handlerError() {
+	if m != nil {
+		m.handlerErrors.Inc()
+	}
+}
+
+// handlerPanic records a panic recovered from a handler.
+func (m *Metrics) handlerPanic() {
+	if m != nil {
+		m.handlerPanics.Inc()
+	}
+}
+
+// handlerTimeout records a handler taking too long with a message.
+func (m *Metrics) handlerTimeout() {
+	if m != nil {
+		m.handlerTimeouts.Inc()
+	}
+}
+
+// pingMiss records a missed ping.
+func (m *Metrics) pingMiss() {
+	if m != nil {
+		m.pingMisses.Inc()
+	}
+}
+
+// reconnect records a reconnect attempt.
+func (m *Metrics) reconnect(err error) {
+	if m == nil {
+		return
+	}
+	outcome := successOutcome
+	if err != nil {
+		outcome = failureOutcome
+	}
+	m.reconnects.WithLabelValues(outcome).Inc()
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+func TestNewMetricsDuplicate(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	_, err := NewMetrics(registry)
+	require.NoError(t, err)
+	_, err = NewMetrics(registry)
+	assert.Error(t, err)
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	assert.NotPanics(t, func() {
+		m.queued(StageEncoder)
+		m.enqueueResult(StageEncoder, ErrQueueFull)
+		m.message(inboundDirection, wrp.SimpleEventMessageType)
+		m.handled(wrp.SimpleEventMessageType, time.Second)
+		m.handlerPanic()
+		m.handlerTimeout()
+		m.pingMiss()
+		m.reconnect(nil)
+	})
+}
+
+func TestClientMetrics(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// echo the first message back to the client, which the handler then
+	// echoes back again.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.WriteMessage(messageType, data)
+		for {
+			if _, _, err = conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	metrics, err := NewMetrics(prometheus.NewPedanticRegistry())
+	require.NoError(err)
+
+	config := clientConfig
+	config.DestinationURL = server.URL
+	config.Metrics = metrics
+	testClient, err := NewClient(config)
+	require.NoError(err)
+	defer testClient.Close()
+
+	err = testClient.SendContext(context.Background(), &wrp.Message{
+		Type:            wrp.SimpleRequestResponseMessageType,
+		Source:          "mac:ffffff112233/emu",
+		Destination:     "mac:ffffff112233/foo",
+		TransactionUUID: "emu:metrics",
+	})
+	require.NoError(err)
+
+	eventType := wrp.SimpleRequestResponseMessageType.FriendlyName()
+	assert.Eventually(func() bool {
+		return testutil.ToFloat64(metrics.messages.WithLabelValues(outboundDirection, eventType)) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(1.0, testutil.ToFloat64(metrics.messages.WithLabelValues(inboundDirection, eventType)))
+	assert.Equal(1, testutil.CollectAndCount(metrics.handlerDuration))
+	assert.Equal(0.0, testutil.ToFloat64(metrics.encodeFailures))
+	assert.Equal(0.0, testutil.ToFloat64(metrics.queueDepth.WithLabelValues(string(StageEncoder))))
+}
@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/xmidt-org/wrp-go/v3"
+	"go.uber.org/zap"
+)
+
+var (
+	errPartnerNotAllowed = errors.New("none of the message's partner ids are allowed")
+	errDecompress        = errors.New("failed to decompress payload")
+)
+
+// gzipMagic starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Middleware wraps a DownstreamHandler to add behavior before or after it
+// handles a message, such as logging or checking the message.  The handler
+// returned should close the handler it wraps when it is closed.  The built-in
+// middlewares are ContextHandlers, which pass the context on; a handler
+// returned by other middleware should be a ContextHandler too, or the
+// handlers it wraps won't be given the context.
+type Middleware func(DownstreamHandler) DownstreamHandler
+
+// Chain combines middlewares into one.  The first middleware is the
+// outermost, so it sees each message first and each response last.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(handler DownstreamHandler) DownstreamHandler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			if middlewares[i] != nil {
+				handler = middlewares[i](handler)
+			}
+		}
+		return handler
+	}
+}
+
+// middlewareHandler is the ContextHandler made by the built-in middlewares.
+// It handles messages with handle, and closes the handler it wraps.
+type middlewareHandler struct {
+	next   DownstreamHandler
+	handle func(context.Context, *wrp.Message) (*wrp.Message, error)
+}
+
+func (m *middlewareHandler) HandleMessage(msg *wrp.Message) *wrp.Message {
+	return respond(msg)(m.handle(context.Background(), msg))
+}
+
+func (m *middlewareHandler) HandleMessageContext(ctx context.Context, msg *wrp.Message) (*wrp.Message, error) {
+	return m.handle(ctx, msg)
+}
+
+func (m *middlewareHandler) Close() {
+	m.next.Close()
+}
+
+// errorResponse creates the error response to a message, from the
+// destination the message was sent to.
+func errorResponse(msg *wrp.Message, statusCode int64, err error) *wrp.Message {
+	return CreateErrorWRP(msg.TransactionUUID, msg.Source, msg.Destination, statusCode, err)
+}
+
+// LogMessages logs every message handled, with how long it took, whether
+// there was a response and any error.
+func LogMessages(logger *zap.Logger) Middleware {
+	return func(next DownstreamHandler) DownstreamHandler {
+		return &middlewareHandler{
+			next: next,
+			handle: func(ctx context.Context, msg *wrp.Message) (*wrp.Message, error) {
+				start := time.Now()
+				response, err := handleContext(ctx, next, msg)
+				fields := []zap.Field{
+					zap.String("type", msg.Type.FriendlyName()),
+					zap.String("source", msg.Source),
+					zap.String("destination", msg.Destination),
+					zap.Duration("duration", time.Since(start)),
+					zap.Bool("response", response != nil),
+				}
+				if msg.TransactionUUID != "" {
+					fields = append(fields, zap.String("transaction", msg.TransactionUUID))
+				}
+				if err != nil {
+					fields = append(fields, zap.Error(err))
+				}
+				logger.Info("Handled message", fields...)
+				return response, err
+			},
+		}
+	}
+}
+
+// RequirePartners only passes on messages with at least one of the partner
+// ids given.  Other messages are answered with a 403 when they have a
+// transaction, and dropped otherwise.
+func RequirePartners(partnerIDs ...string) Middleware {
+	return func(next DownstreamHandler) DownstreamHandler {
+		return &middlewareHandler{
+			next: next,
+			handle: func(ctx context.Context, msg *wrp.Message) (*wrp.Message, error) {
+				for _, id := range msg.PartnerIDs {
+					if slices.Contains(partnerIDs, id) {
+						return handleContext(ctx, next, msg)
+					}
+				}
+				if msg.TransactionUUID == "" {
+					return nil, nil
+				}
+				return nil, NewStatusError(http.StatusForbidden, errPartnerNotAllowed)
+			},
+		}
+	}
+}
+
+// DecompressPayload decompresses gzip payloads before passing the message
+// on.  Payloads that aren't gzip are passed on as they are.  A payload that
+// can't be decompressed is answered with a 400 when the message has a
+// transaction, and dropped otherwise.
+func DecompressPayload() Middleware {
+	return func(next DownstreamHandler) DownstreamHandler {
+		return &middlewareHandler{
+			next: next,
+			handle: func(ctx context.Context, msg *wrp.Message) (*wrp.Message, error) {
+				if !bytes.HasPrefix(msg.Payload, gzipMagic) {
+					return handleContext(ctx, next, msg)
+				}
+				payload, err := gunzip(msg.Payload)
+				if err != nil {
+					if msg.TransactionUUID == "" {
+						return nil, nil
+					}
+					return nil, NewStatusError(http.StatusBadRequest, err)
+				}
+				decompressed := *msg
+				decompressed.Payload = payload
+				return handleContext(ctx, next, &decompressed)
+			},
+		}
+	}
+}
+
+// gunzip decompresses a gzip payload.
+func gunzip(payload []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errDecompress, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errDecompress, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v3"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// echoHandler responds with the message it is given, remembering the
+// messages it handled and whether it was closed.
+type echoHandler struct {
+	handled []*wrp.Message
+	closed  bool
+}
+
+func (e *echoHandler) HandleMessage(msg *wrp.Message) *wrp.Message {
+	e.handled = append(e.handled, msg)
+	return msg
+}
+
+func (e *echoHandler) Close() {
+	e.closed = true
+}
+
+// tagMiddleware appends its name to the payload of messages on the way in
+// and to the payload of responses on the way out.
+func tagMiddleware(name string) Middleware {
+	return func(next DownstreamHandler) DownstreamHandler {
+		return &middlewareHandler{
+			next: next,
+			handle: func(ctx context.Context, msg *wrp.Message) (*wrp.Message, error) {
+				tagged := *msg
+				tagged.Payload = append(append([]byte{}, msg.Payload...), name+">"...)
+				response, err := handleContext(ctx, next, &tagged)
+				response.Payload = append(response.Payload, "<"+name...)
+				return response, err
+			},
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	assert := assert.New(t)
+
+	next := &echoHandler{}
+	handler := Chain(tagMiddleware("a"), nil, tagMiddleware("b"))(next)
+	response := handler.HandleMessage(&wrp.Message{})
+	assert.Equal("a>b><b<a", string(response.Payload))
+
+	handler.Close()
+	assert.True(next.closed)
+
+	assert.Same(next, Chain()(next))
+}
+
+func TestHandlerRegistryMiddleware(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	route := &echoHandler{}
+	other := &echoHandler{}
+	registry, err := NewHandlerRegistry([]HandlerConfig{
+		{Regexp: "/route", Handler: route, Middleware: []Middleware{tagMiddleware("route")}},
+	})
+	require.NoError(err)
+	registry.Use(tagMiddleware("global"))
+	require.NoError(registry.Add("/other", other))
+
+	handler, err := registry.GetHandler("/route")
+	require.NoError(err)
+	assert.Equal("global>route><route<global", string(handler.HandleMessage(&wrp.Message{}).Payload))
+
+	handler, err = registry.GetHandler("/other")
+	require.NoError(err)
+	assert.Equal("global><global", string(handler.HandleMessage(&wrp.Message{}).Payload))
+
+	handlers := registry.Handlers()
+	require.Len(handlers, 2)
+	assert.Same(route, handlers[0].Handler)
+	assert.Len(handlers[0].Middleware, 1)
+
+	registry.Close()
+	assert.True(route.closed)
+	assert.True(other.closed)
+}
+
+func TestLogMessages(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	core, logs := observer.New(zap.InfoLevel)
+	handler := LogMessages(zap.New(core))(&echoHandler{})
+	handler.HandleMessage(&wrp.Message{
+		Type:            wrp.SimpleRequestResponseMessageType,
+		Source:          "dns:talaria",
+		Destination:     "mac:112233445566/config",
+		TransactionUUID: "123",
+	})
+
+	entries := logs.All()
+	require.Len(entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal("dns:talaria", fields["source"])
+	assert.Equal("mac:112233445566/config", fields["destination"])
+	assert.Equal("123", fields["transaction"])
+	assert.Equal(true, fields["response"])
+}
+
+func TestRequirePartners(t *testing.T) {
+	assert := assert.New(t)
+
+	next := &echoHandler{}
+	handler := RequirePartners("comcast", "sky")(next)
+
+	msg := &wrp.Message{PartnerIDs: []string{"other", "sky"}}
+	assert.Same(msg, handler.HandleMessage(msg))
+
+	assert.Nil(handler.HandleMessage(&wrp.Message{PartnerIDs: []string{"other"}}))
+
+	response := handler.HandleMessage(&wrp.Message{
+		Source:          "dns:talaria",
+		Destination:     "mac:112233445566/config",
+		TransactionUUID: "123",
+	})
+	assert.Equal("dns:talaria", response.Destination)
+	assert.Equal("mac:112233445566/config", response.Source)
+	assert.Equal("123", response.TransactionUUID)
+	assert.Equal(int64(http.StatusForbidden), *response.Status)
+	assert.Len(next.handled, 1)
+}
+
+func TestDecompressPayload(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte("hello"))
+	require.NoError(err)
+	require.NoError(w.Close())
+
+	next := &echoHandler{}
+	handler := DecompressPayload()(next)
+
+	msg := &wrp.Message{Payload: buf.Bytes()}
+	assert.Equal("hello", string(handler.HandleMessage(msg).Payload))
+	assert.Equal(buf.Bytes(), msg.Payload)
+
+	assert.Equal("plain", string(handler.HandleMessage(&wrp.Message{Payload: []byte("plain")}).Payload))
+
+	assert.Nil(handler.HandleMessage(&wrp.Message{Payload: gzipMagic}))
+	response := handler.HandleMessage(&wrp.Message{Payload: gzipMagic, TransactionUUID: "123"})
+	assert.Equal(int64(http.StatusBadRequest), *response.Status)
+	assert.Len(next.handled, 2)
+}
@@ -0,0 +1,456 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/xmidt-org/wrp-go/v3"
+	"go.uber.org/zap"
+)
+
+var (
+	errNilOption          = errors.New("option value must not be nil")
+	errUnknownStage       = errors.New("unknown queue stage")
+	errOutboundWorkers    = errors.New("the outbound queue has a single writer, so MaxWorkers must be 0 or 1")
+	errNonPositive        = errors.New("value must be greater than zero")
+	errConflictingOptions = errors.New("conflicting options")
+)
+
+// Option configures a Client made by New.
+type Option interface {
+	apply(*ClientConfig) error
+}
+
+type optionFunc func(*ClientConfig) error
+
+func (f optionFunc) apply(c *ClientConfig) error {
+	return f(c)
+}
+
+// optionError names the option that an error came from.
+func optionError(option string, err error) error {
+	return fmt.Errorf("%s: %w", option, err)
+}
+
+// New creates a Client from options, as an alternative to NewClient.  The
+// device name, destination URL and ping miss handler are required.  Every
+// option is checked, along with how the options combine, before connecting,
+// and all of the problems found are returned together.
+//
+// Unlike NewClient, the defaults are applied before the options rather than
+// in place of zero values: the ping wait is DefaultPingWait and the number of
+// missed pings tolerated is DefaultMaxPingMiss.
+func New(opts ...Option) (Client, error) {
+	config := ClientConfig{
+		PingConfig: PingConfig{
+			PingWait:    DefaultPingWait,
+			MaxPingMiss: DefaultMaxPingMiss,
+		},
+	}
+
+	var errs errorList
+	for _, o := range opts {
+		if o == nil {
+			continue
+		}
+		err := o.apply(&config)
+		var list errorList
+		switch {
+		case errors.As(err, &list):
+			errs = append(errs, list...)
+		case err != nil:
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, validateOptions(config)...)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return NewClient(config)
+}
+
+// validateOptions checks the required options, and the options that depend
+// on each other.
+func validateOptions(config ClientConfig) errorList {
+	var errs errorList
+	if config.DeviceName == "" {
+		errs = append(errs, optionError("WithDeviceName", errRequired))
+	}
+	if config.DestinationURL == "" {
+		errs = append(errs, optionError("WithDestinationURL", errRequired))
+	}
+	if config.HandlePingMiss == nil {
+		errs = append(errs, optionError("WithPingMissHandler", errRequired))
+	}
+	if config.TLS != nil && config.Dialer != nil {
+		if d, ok := config.Dialer.(*websocket.Dialer); !ok || d.TLSClientConfig != nil {
+			errs = append(errs, fmt.Errorf("%w: WithTLS and a WithDialer with its own TLS", errConflictingOptions))
+		}
+	}
+	if config.Dialer != nil && config.DialerConfig != (DialerConfig{}) {
+		errs = append(errs, fmt.Errorf("%w: WithDialer and WithDialerConfig", errConflictingOptions))
+	}
+	return errs
+}
+
+// WithDeviceName sets the device ID the client connects as, such as
+// mac:112233445566.
+func WithDeviceName(name string) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if _, err := wrp.ParseDeviceID(name); err != nil {
+			return optionError("WithDeviceName", err)
+		}
+		c.DeviceName = name
+		return nil
+	})
+}
+
+// WithDeviceInfo sets the firmware, model and manufacturer the device reports
+// when it connects.
+func WithDeviceInfo(firmware, model, manufacturer string) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		c.FirmwareName = firmware
+		c.ModelName = model
+		c.Manufacturer = manufacturer
+		return nil
+	})
+}
+
+// WithBootTime sets when the device last booted, and why.
+func WithBootTime(bootTime time.Time, lastRebootReason string) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		c.BootTime = bootTime
+		c.LastRebootReason = lastRebootReason
+		return nil
+	})
+}
+
+// WithInterfaceUsed sets the network interface the device reports connecting
+// over, such as erouter0.
+func WithInterfaceUsed(name string) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		c.InterfaceUsed = name
+		return nil
+	})
+}
+
+// WithProtocol sets the X-Webpa-Protocol header.
+func WithProtocol(protocol string) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		c.Protocol = protocol
+		return nil
+	})
+}
+
+// WithUserAgent sets the User-Agent header.
+func WithUserAgent(userAgent string) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		c.UserAgent = userAgent
+		return nil
+	})
+}
+
+// WithConvey sets the values sent, as base64 JSON, in the X-Webpa-Convey
+// header.
+func WithConvey(convey map[string]any) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if _, err := encodeConvey(convey); err != nil {
+			return optionError("WithConvey", err)
+		}
+		c.Convey = convey
+		return nil
+	})
+}
+
+// WithHeader adds a header to the ones the device connects with.  It replaces
+// any of the standard headers with the same name.
+func WithHeader(name, value string) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if c.Headers == nil {
+			c.Headers = make(http.Header)
+		}
+		c.Headers.Add(name, value)
+		return nil
+	})
+}
+
+// WithDestinationURL sets the URL of XMiDT.  http and https URLs are
+// connected to as ws and wss.
+func WithDestinationURL(destinationURL string) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if _, err := websocketURL(destinationURL); err != nil {
+			return optionError("WithDestinationURL", err)
+		}
+		c.DestinationURL = destinationURL
+		return nil
+	})
+}
+
+// WithPingMissHandler sets the function called when pings stop arriving.
+func WithPingMissHandler(handlePingMiss HandlePingMiss) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if handlePingMiss == nil {
+			return optionError("WithPingMissHandler", errNilOption)
+		}
+		c.HandlePingMiss = handlePingMiss
+		return nil
+	})
+}
+
+// WithPing sets how long to wait for each ping, and how many can be missed.
+// Both must be greater than zero.
+func WithPing(pingWait time.Duration, maxPingMiss int) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if pingWait <= 0 || maxPingMiss <= 0 {
+			return optionError("WithPing", errNonPositive)
+		}
+		c.PingConfig = PingConfig{PingWait: pingWait, MaxPingMiss: maxPingMiss}
+		return nil
+	})
+}
+
+// WithTLS sets the TLS used for wss connections.  It can't be combined with a
+// WithDialer that has its own TLS.
+func WithTLS(config *TLSConfig) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if config == nil {
+			return optionError("WithTLS", errNilOption)
+		}
+		if _, err := config.NewTLSConfig(); err != nil {
+			return optionError("WithTLS", err)
+		}
+		c.TLS = config
+		return nil
+	})
+}
+
+// WithDialer sets the Dialer the websocket connection is made with.  A
+// *websocket.Dialer is copied, so it can be shared.  It can't be combined
+// with WithDialerConfig.
+func WithDialer(dialer Dialer) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if dialer == nil {
+			return optionError("WithDialer", errNilOption)
+		}
+		c.Dialer = dialer
+		return nil
+	})
+}
+
+// WithDialerConfig configures the dialer the client makes, such as to use a
+// proxy, a local address or a unix socket.
+func WithDialerConfig(config DialerConfig) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if err := config.validate(); err != nil {
+			return optionError("WithDialerConfig", err)
+		}
+		c.DialerConfig = config
+		return nil
+	})
+}
+
+// WithRedirectPolicy sets which redirects are followed when dialing.
+func WithRedirectPolicy(policy RedirectPolicy) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		for _, code := range policy.StatusCodes {
+			if code < 300 || code > 399 {
+				return optionError(fmt.Sprintf("WithRedirectPolicy(%d)", code), errRedirectCode)
+			}
+		}
+		c.Redirect = policy
+		return nil
+	})
+}
+
+// WithHostnameFunc sets how the client's Hostname is found from the URL it
+// connects to.
+func WithHostnameFunc(hostnameFunc func(connectionURL string) string) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if hostnameFunc == nil {
+			return optionError("WithHostnameFunc", errNilOption)
+		}
+		c.HostnameFunc = hostnameFunc
+		return nil
+	})
+}
+
+// WithLogger sets the logger used by the client and all of its queues.
+func WithLogger(logger *zap.Logger) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if logger == nil {
+			return optionError("WithLogger", errNilOption)
+		}
+		c.ClientLogger = logger
+		return nil
+	})
+}
+
+// WithQueue configures the queue of one Stage.
+func WithQueue(stage Stage, config QueueConfig) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		name := fmt.Sprintf("WithQueue(%s)", stage)
+		var errs errorList
+		if err := notNegative(config.MaxWorkers); err != nil {
+			errs = append(errs, optionError(name+" MaxWorkers", err))
+		}
+		if err := notNegative(config.Size); err != nil {
+			errs = append(errs, optionError(name+" Size", err))
+		}
+		if err := config.Overflow.validate(); err != nil {
+			errs = append(errs, optionError(name+" Overflow", err))
+		}
+		if err := notNegative(config.Timeout); err != nil {
+			errs = append(errs, optionError(name+" Timeout", err))
+		}
+
+		var queue *QueueConfig
+		switch stage {
+		case StageOutbound:
+			if config.MaxWorkers > 1 {
+				errs = append(errs, optionError(name, errOutboundWorkers))
+			}
+			queue = &c.OutboundQueue
+		case StageEncoder:
+			queue = &c.WRPEncoderQueue
+		case StageDecoder:
+			queue = &c.WRPDecoderQueue
+		case StageRegistry:
+			queue = &c.HandlerRegistryQueue
+		case StageHandler:
+			queue = &c.HandleMsgQueue
+		default:
+			errs = append(errs, optionError(name, errUnknownStage))
+		}
+
+		if len(errs) > 0 {
+			return errs
+		}
+		*queue = config
+		return nil
+	})
+}
+
+// WithHandler adds a handler for the messages with destinations matching the
+// handler's Regexp.
+func WithHandler(handler HandlerConfig) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		name := fmt.Sprintf("WithHandler(%s)", handler.Regexp)
+		if handler.Handler == nil {
+			return optionError(name, errNilOption)
+		}
+		if _, err := regexp.Compile(handler.Regexp); err != nil {
+			return optionError(name, err)
+		}
+		if err := notNegative(handler.Timeout); err != nil {
+			return optionError(name+" Timeout", err)
+		}
+		for _, m := range handler.Match {
+			if m == nil {
+				return optionError(name+" Match", errNilOption)
+			}
+		}
+		c.Handlers = append(c.Handlers, handler)
+		return nil
+	})
+}
+
+// WithHandlerOrder sets how handlers with the same priority are chosen
+// between.
+func WithHandlerOrder(order HandlerOrder) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if err := order.validate(); err != nil {
+			return optionError("WithHandlerOrder", err)
+		}
+		c.HandlerOrder = order
+		return nil
+	})
+}
+
+// WithMiddleware adds middleware that wraps every handler.  Middleware added
+// first is the outermost.
+func WithMiddleware(middleware ...Middleware) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		for _, m := range middleware {
+			if m == nil {
+				return optionError("WithMiddleware", errNilOption)
+			}
+		}
+		c.Middleware = append(c.Middleware, middleware...)
+		return nil
+	})
+}
+
+// WithWrite configures how messages are written to the websocket.
+func WithWrite(config WriteConfig) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if notNegative(config.Timeout) != nil || notNegative(config.MessagesPerDeadline) != nil {
+			return optionError("WithWrite", errNegative)
+		}
+		c.WriteConfig = config
+		return nil
+	})
+}
+
+// WithReconnect configures reconnecting when the connection is lost.
+func WithReconnect(config ReconnectConfig) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		var errs errorList
+		if notNegative(config.InitialInterval) != nil || notNegative(config.MaxInterval) != nil || notNegative(config.MaxElapsedTime) != nil {
+			errs = append(errs, optionError("WithReconnect", errNegative))
+		}
+		if config.Multiplier != 0 && config.Multiplier < 1 {
+			errs = append(errs, optionError("WithReconnect Multiplier", errMultiplier))
+		}
+		if config.Jitter < 0 || config.Jitter > 1 {
+			errs = append(errs, optionError("WithReconnect Jitter", errJitter))
+		}
+		if config.MaxInterval != 0 && config.MaxInterval < config.InitialInterval {
+			errs = append(errs, fmt.Errorf("%w: WithReconnect MaxInterval is less than InitialInterval", errConflictingOptions))
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		c.Reconnect = config
+		return nil
+	})
+}
+
+// WithTokenAcquirer sets where the Authorization header comes from.
+func WithTokenAcquirer(acquirer TokenAcquirer) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if acquirer == nil {
+			return optionError("WithTokenAcquirer", errNilOption)
+		}
+		c.TokenAcquirer = acquirer
+		return nil
+	})
+}
+
+// WithListener adds a listener for connection events.
+func WithListener(listener ClientListener) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if listener == nil {
+			return optionError("WithListener", errNilOption)
+		}
+		c.Listeners = append(c.Listeners, listener)
+		return nil
+	})
+}
+
+// WithMetrics sets where the client's prometheus metrics are recorded.
+func WithMetrics(metrics *Metrics) Option {
+	return optionFunc(func(c *ClientConfig) error {
+		if metrics == nil {
+			return optionError("WithMetrics", errNilOption)
+		}
+		c.Metrics = metrics
+		return nil
+	})
+}
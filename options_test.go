@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"crypto/tls"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNewOptions(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	recorder, listener := newRecordingListener()
+	testClient, err := New(
+		WithDeviceName(clientConfig.DeviceName),
+		WithDeviceInfo("firmware", "model", "manufacturer"),
+		WithDestinationURL(testServer.URL),
+		WithPingMissHandler(func() error { return nil }),
+		WithPing(time.Second, 2),
+		WithDialer(&websocket.Dialer{HandshakeTimeout: time.Second}),
+		WithLogger(zap.NewNop()),
+		WithQueue(StageEncoder, QueueConfig{MaxWorkers: 2, Size: 5}),
+		WithHandler(HandlerConfig{Regexp: "/config", Handler: &myReadHandler{}}),
+		WithListener(listener),
+		WithMiddleware(LogMessages(zap.NewNop())),
+		nil,
+	)
+	require.NoError(err)
+	defer testClient.Close()
+
+	c := testClient.(*client)
+	assert.Equal(PingConfig{PingWait: time.Second, MaxPingMiss: 2}, c.pingConfig)
+	assert.Equal(time.Second, c.dialer.(*websocket.Dialer).HandshakeTimeout)
+	assert.Equal("firmware", c.headerInfo.firmwareName)
+	assert.Len(recorder.get("connect"), 1)
+	assert.Len(c.registry.(*handlerRegistry).middleware, 1)
+}
+
+func TestNewDefaults(t *testing.T) {
+	testClient, err := New(
+		WithDeviceName(clientConfig.DeviceName),
+		WithDestinationURL(testServer.URL),
+		WithPingMissHandler(func() error { return nil }),
+	)
+	require.NoError(t, err)
+	defer testClient.Close()
+
+	c := testClient.(*client)
+	assert.Equal(t, PingConfig{PingWait: DefaultPingWait, MaxPingMiss: DefaultMaxPingMiss}, c.pingConfig)
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		description  string
+		options      []Option
+		expectedErrs []error
+	}{
+		{
+			description:  "nothing required",
+			expectedErrs: []error{errRequired, errRequired, errRequired},
+		},
+		{
+			description: "bad values",
+			options: []Option{
+				WithDeviceName("nothing"),
+				WithDestinationURL("ftp://talaria"),
+				WithPingMissHandler(nil),
+				WithPing(0, 1),
+				WithLogger(nil),
+				WithRedirectPolicy(RedirectPolicy{StatusCodes: []int{404}}),
+				WithMiddleware(LogMessages(zap.NewNop()), nil),
+			},
+			expectedErrs: []error{
+				nil, nil, errNilOption, errNonPositive, errNilOption, errRedirectCode, errNilOption,
+				errRequired, errRequired, errRequired,
+			},
+		},
+		{
+			description: "bad queues",
+			options: []Option{
+				WithDeviceName(clientConfig.DeviceName),
+				WithDestinationURL(testServer.URL),
+				WithPingMissHandler(func() error { return nil }),
+				WithQueue(StageOutbound, QueueConfig{MaxWorkers: 2}),
+				WithQueue(Stage("nowhere"), QueueConfig{}),
+				WithQueue(StageDecoder, QueueConfig{Size: -1, Overflow: "spill"}),
+			},
+			expectedErrs: []error{errOutboundWorkers, errUnknownStage, errNegative, nil},
+		},
+		{
+			description: "conflicts",
+			options: []Option{
+				WithDeviceName(clientConfig.DeviceName),
+				WithDestinationURL(testServer.URL),
+				WithPingMissHandler(func() error { return nil }),
+				WithTLS(&TLSConfig{}),
+				WithDialer(&websocket.Dialer{TLSClientConfig: &tls.Config{}}), //nolint:gosec
+				WithReconnect(ReconnectConfig{InitialInterval: time.Minute, MaxInterval: time.Second}),
+				WithDialerConfig(DialerConfig{LocalAddr: "127.0.0.1"}),
+				WithDialerConfig(DialerConfig{LocalAddr: "localhost"}),
+			},
+			expectedErrs: []error{errConflictingOptions, errInvalidLocalAddr, errConflictingOptions, errConflictingOptions},
+		},
+		{
+			description: "bad handlers",
+			options: []Option{
+				WithDeviceName(clientConfig.DeviceName),
+				WithDestinationURL(testServer.URL),
+				WithPingMissHandler(func() error { return nil }),
+				WithHandler(HandlerConfig{Regexp: "(", Handler: &myReadHandler{}}),
+				WithHandler(HandlerConfig{Regexp: "/config"}),
+				WithHandlerOrder("random"),
+				WithTLS(&TLSConfig{KeyFile: "key.pem"}),
+			},
+			expectedErrs: []error{nil, errNilOption, nil, errIncompleteKeyPair},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			testClient, err := New(tc.options...)
+			assert.Nil(t, testClient)
+
+			var list errorList
+			require.True(t, errors.As(err, &list))
+			require.Len(t, list, len(tc.expectedErrs), err.Error())
+			for i, expected := range tc.expectedErrs {
+				if expected == nil {
+					assert.Error(t, list[i])
+					continue
+				}
+				assert.ErrorIs(t, list[i], expected)
+			}
+		})
+	}
+}
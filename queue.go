@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"fmt"
+)
+
+// Stage names one of the queues that messages flow through.
+type Stage string
+
+const (
+	// StageOutbound is the queue of encoded messages waiting to be written to
+	// the websocket.
+	StageOutbound Stage = "outbound"
+
+	// StageEncoder is the queue of messages waiting to be encoded.
+	StageEncoder Stage = "encoder"
+
+	// StageDecoder is the queue of messages waiting to be decoded.
+	StageDecoder Stage = "decoder"
+
+	// StageRegistry is the queue of decoded messages waiting for a handler to
+	// be found.
+	StageRegistry Stage = "registry"
+
+	// StageHandler is the queue of messages waiting to be handled.
+	StageHandler Stage = "handler"
+)
+
+// OverflowPolicy decides what a queue does with a new message when it is full.
+type OverflowPolicy string
+
+const (
+	// OverflowBlock waits until there is room in the queue.  This is the
+	// default.
+	OverflowBlock OverflowPolicy = "block"
+
+	// OverflowDropNewest discards the new message.
+	OverflowDropNewest OverflowPolicy = "drop-newest"
+
+	// OverflowDropOldest discards the oldest message in the queue to make
+	// room for the new message.
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+
+	// OverflowFailFast rejects the new message with ErrQueueFull.
+	OverflowFailFast OverflowPolicy = "fail-fast"
+)
+
+// validate checks that the OverflowPolicy is one that is known.
+func (p OverflowPolicy) validate() error {
+	switch p {
+	case "", OverflowBlock, OverflowDropNewest, OverflowDropOldest, OverflowFailFast:
+		return nil
+	default:
+		return fmt.Errorf("unknown overflow policy [%v]", p)
+	}
+}
+
+// enqueue adds the item to the queue, following the policy when the queue is
+// full.  Items discarded to make room are given to onDrop.
+func enqueue[T any](ctx context.Context, queue chan T, item T, policy OverflowPolicy, onDrop func(T)) error {
+	switch policy {
+	case OverflowDropNewest:
+		select {
+		case queue <- item:
+			return nil
+		default:
+			return ErrMessageDropped
+		}
+	case OverflowFailFast:
+		select {
+		case queue <- item:
+			return nil
+		default:
+			return ErrQueueFull
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case queue <- item:
+				return nil
+			default:
+			}
+			select {
+			case oldest := <-queue:
+				onDrop(oldest)
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+		}
+	default:
+		select {
+		case queue <- item:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/sallust"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+func TestEnqueue(t *testing.T) {
+	tests := []struct {
+		policy          OverflowPolicy
+		expectedErr     error
+		expectedQueue   []int
+		expectedDropped []int
+	}{
+		{
+			policy:        OverflowBlock,
+			expectedErr:   context.DeadlineExceeded,
+			expectedQueue: []int{1},
+		},
+		{
+			policy:        OverflowDropNewest,
+			expectedErr:   ErrMessageDropped,
+			expectedQueue: []int{1},
+		},
+		{
+			policy:          OverflowDropOldest,
+			expectedQueue:   []int{2},
+			expectedDropped: []int{1},
+		},
+		{
+			policy:        OverflowFailFast,
+			expectedErr:   ErrQueueFull,
+			expectedQueue: []int{1},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			assert := assert.New(t)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			queue := make(chan int, 1)
+			var dropped []int
+			onDrop := func(i int) { dropped = append(dropped, i) }
+
+			assert.NoError(enqueue(ctx, queue, 1, tc.policy, onDrop))
+			assert.ErrorIs(enqueue(ctx, queue, 2, tc.policy, onDrop), tc.expectedErr)
+
+			close(queue)
+			var queued []int
+			for i := range queue {
+				queued = append(queued, i)
+			}
+			assert.Equal(tc.expectedQueue, queued)
+			assert.Equal(tc.expectedDropped, dropped)
+		})
+	}
+}
+
+func TestOverflowPolicyValidate(t *testing.T) {
+	assert := assert.New(t)
+	assert.NoError(OverflowPolicy("").validate())
+	assert.NoError(OverflowDropOldest.validate())
+	assert.Error(OverflowPolicy("drop-everything").validate())
+
+	config := clientConfig
+	config.WRPEncoderQueue.Overflow = "drop-everything"
+	_, err := NewClient(config)
+	assert.Error(err)
+}
+
+func TestSendContext(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fakeConn := &mockConnection{}
+	fakeConn.On("SetWriteDeadline", mock.AnythingOfType("time.Time")).Return(nil).Once()
+	fakeConn.On("WriteMessage", mock.Anything, mock.AnythingOfType("[]uint8")).Return(nil).Once()
+
+	logger := sallust.Default()
+	sender := NewSender(fakeConn, QueueConfig{MaxWorkers: 1, Size: 1}, WriteConfig{}, nil, logger)
+	encoder := NewEncoderSender(sender, QueueConfig{MaxWorkers: 1, Size: 1}, nil, logger)
+	testClient := &client{
+		encoderSender: encoder,
+		logger:        logger,
+	}
+
+	err := testClient.SendContext(context.Background(), &wrp.Message{
+		Type:        wrp.SimpleEventMessageType,
+		Source:      "mac:ffffff112233/emu",
+		Destination: "event:device-status/bla/bla",
+	})
+	require.NoError(err)
+
+	encoder.Close()
+	err = testClient.SendContext(context.Background(), &wrp.Message{})
+	assert.ErrorIs(err, ErrClientClosed)
+	fakeConn.AssertExpectations(t)
+}
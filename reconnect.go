@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultReconnectInitialInterval = time.Second
+	defaultReconnectMaxInterval     = time.Minute
+	defaultReconnectMultiplier      = 2.0
+)
+
+var (
+	errReconnectTimeout = errors.New("failed to reconnect within the max elapsed time")
+)
+
+// ReconnectConfig configures how the client re-establishes the websocket
+// connection to XMiDT after it is lost.  The wait between attempts grows
+// exponentially from InitialInterval up to MaxInterval.
+type ReconnectConfig struct {
+	// Enabled turns on the reconnect loop.  When false, the client stops
+	// reading messages once the connection is lost.
+	Enabled bool `yaml:"enabled"`
+
+	// InitialInterval is the wait before the first reconnect attempt.
+	// Defaults to one second.
+	InitialInterval time.Duration `yaml:"initialInterval"`
+
+	// MaxInterval caps the wait between reconnect attempts.  Defaults to one
+	// minute.
+	MaxInterval time.Duration `yaml:"maxInterval"`
+
+	// Multiplier is applied to the wait after every failed attempt.  Defaults
+	// to 2.
+	Multiplier float64 `yaml:"multiplier"`
+
+	// Jitter is the randomization factor, between 0 and 1, applied to every
+	// wait so that many devices do not reconnect in lockstep.  A wait of w is
+	// randomized to somewhere in [w - Jitter*w, w + Jitter*w].  Zero disables
+	// jitter.
+	Jitter float64 `yaml:"jitter"`
+
+	// MaxElapsedTime is how long to keep trying before giving up.  Zero means
+	// the client keeps trying until it is closed.
+	MaxElapsedTime time.Duration `yaml:"maxElapsedTime"`
+}
+
+// backoff calculates the wait between reconnect attempts.
+type backoff struct {
+	config  ReconnectConfig
+	current time.Duration
+	start   time.Time
+	now     func() time.Time
+	random  func() float64
+}
+
+// newBackoff creates a backoff from the ReconnectConfig, filling in defaults
+// for any values that are unset.
+func newBackoff(config ReconnectConfig) *backoff {
+	if config.InitialInterval <= 0 {
+		config.InitialInterval = defaultReconnectInitialInterval
+	}
+	if config.MaxInterval <= 0 {
+		config.MaxInterval = defaultReconnectMaxInterval
+	}
+	if config.MaxInterval < config.InitialInterval {
+		config.MaxInterval = config.InitialInterval
+	}
+	if config.Multiplier < 1 {
+		config.Multiplier = defaultReconnectMultiplier
+	}
+	if config.Jitter < 0 {
+		config.Jitter = 0
+	}
+	if config.Jitter > 1 {
+		config.Jitter = 1
+	}
+	b := &backoff{
+		config: config,
+		now:    time.Now,
+		random: rand.Float64, //nolint:gosec
+	}
+	b.reset()
+	return b
+}
+
+// reset starts the backoff over from the initial interval.
+func (b *backoff) reset() {
+	b.current = b.config.InitialInterval
+	b.start = b.now()
+}
+
+// next returns the wait before the next attempt.  False is returned once the
+// max elapsed time has passed.
+func (b *backoff) next() (time.Duration, bool) {
+	wait := b.current
+	if b.config.Jitter > 0 {
+		delta := b.config.Jitter * float64(wait)
+		wait = time.Duration(float64(wait) - delta + b.random()*2*delta)
+	}
+
+	b.current = time.Duration(float64(b.current) * b.config.Multiplier)
+	if b.current > b.config.MaxInterval {
+		b.current = b.config.MaxInterval
+	}
+
+	if b.config.MaxElapsedTime > 0 && b.now().Add(wait).Sub(b.start) > b.config.MaxElapsedTime {
+		return 0, false
+	}
+	return wait, true
+}
+
+// reconnect dials XMiDT until a new connection is established, the max
+// elapsed time is reached, or the client is closed.  Closing the client also
+// stops an attempt that is acquiring a token or dialing.
+func (c *client) reconnect() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-c.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	b := newBackoff(c.reconnectConfig)
+	for attempt := 1; ; attempt++ {
+		wait, ok := b.next()
+		if !ok {
+			return errReconnectTimeout
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-c.done:
+			timer.Stop()
+			return ErrClientClosed
+		case <-timer.C:
+		}
+
+		c.logger.Info("Reconnecting...", zap.Int("attempt", attempt))
+		newConnection, info, err := c.dial(ctx, attempt)
+		if ctx.Err() != nil {
+			if newConnection != nil {
+				newConnection.Close()
+			}
+			return ErrClientClosed
+		}
+		if err != nil {
+			c.logger.Warn("Failed to reconnect", zap.Int("attempt", attempt), zap.Error(err))
+			c.metrics.reconnect(err)
+			c.listeners.onReconnect(newConnectionEvent(c.destinationURL, attempt, err))
+			continue
+		}
+		if err = c.connection.set(newConnection); err != nil {
+			return err
+		}
+		c.setConnectionInfo(info)
+		c.logger.Info("Reconnected", zap.Int("attempt", attempt), zap.String("url", info.url))
+		c.metrics.reconnect(nil)
+		c.listeners.onReconnect(newConnectionEvent(info.url, attempt, nil))
+		c.listeners.onConnect(newConnectionEvent(info.url, attempt, nil))
+		return nil
+	}
+}
@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	b := newBackoff(ReconnectConfig{
+		InitialInterval: time.Second,
+		MaxInterval:     5 * time.Second,
+		Multiplier:      2,
+		MaxElapsedTime:  20 * time.Second,
+	})
+	b.now = func() time.Time { return now }
+	b.reset()
+
+	expected := []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+	}
+	for _, e := range expected {
+		wait, ok := b.next()
+		assert.True(ok)
+		assert.Equal(e, wait)
+	}
+
+	now = now.Add(18 * time.Second)
+	_, ok := b.next()
+	assert.False(ok)
+}
+
+func TestBackoffJitter(t *testing.T) {
+	assert := assert.New(t)
+	b := newBackoff(ReconnectConfig{
+		InitialInterval: 10 * time.Second,
+		Jitter:          0.5,
+	})
+
+	b.random = func() float64 { return 0 }
+	wait, ok := b.next()
+	assert.True(ok)
+	assert.Equal(5*time.Second, wait)
+
+	b.reset()
+	b.random = func() float64 { return 1 }
+	wait, ok = b.next()
+	assert.True(ok)
+	assert.Equal(15*time.Second, wait)
+}
+
+func TestReconnect(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var connects atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		// drop the first connection so the client needs to reconnect.
+		if connects.Add(1) == 1 {
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+
+	config := clientConfig
+	config.DestinationURL = server.URL
+	config.Reconnect = ReconnectConfig{
+		Enabled:         true,
+		InitialInterval: 10 * time.Millisecond,
+	}
+	testClient, err := NewClient(config)
+	require.NoError(err)
+
+	assert.Eventually(func() bool {
+		return connects.Load() >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal("127.0.0.1", testClient.Hostname())
+	assert.NoError(testClient.Close())
+}
+
+func TestReconnectClose(t *testing.T) {
+	tests := []struct {
+		description string
+		// blockToken makes reconnect attempts wait on the token acquirer,
+		// otherwise they wait on the websocket handshake.
+		blockToken bool
+	}{
+		{description: "Token", blockToken: true},
+		{description: "Handshake"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var connects atomic.Int32
+			reconnecting := make(chan struct{}, 1)
+			release := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if connects.Add(1) > 1 {
+					// don't answer the handshake of a reconnect attempt until
+					// the test is over.
+					reconnecting <- struct{}{}
+					<-release
+					return
+				}
+				// drop the first connection so the client needs to reconnect.
+				if conn, err := upgrader.Upgrade(w, r, nil); err == nil {
+					conn.Close()
+				}
+			}))
+			defer server.Close()
+			defer close(release)
+
+			var acquires atomic.Int32
+			config := clientConfig
+			config.DestinationURL = server.URL
+			config.Reconnect = ReconnectConfig{
+				Enabled:         true,
+				InitialInterval: 10 * time.Millisecond,
+			}
+			config.TokenAcquirer = TokenAcquirerFunc(func(ctx context.Context) (Token, error) {
+				if tc.blockToken && acquires.Add(1) > 1 {
+					reconnecting <- struct{}{}
+					<-ctx.Done()
+					return Token{}, ctx.Err()
+				}
+				return Token{Value: "token"}, nil
+			})
+			testClient, err := NewClient(config)
+			require.NoError(err)
+
+			select {
+			case <-reconnecting:
+			case <-time.After(5 * time.Second):
+				require.Fail("the client did not try to reconnect")
+			}
+			closed := make(chan error, 1)
+			go func() {
+				closed <- testClient.Close()
+			}()
+			select {
+			case err = <-closed:
+				assert.NoError(err)
+			case <-time.After(5 * time.Second):
+				require.Fail("closing the client waited on the reconnect attempt")
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	// DefaultMaxRedirects is the most redirects followed when
+	// RedirectPolicy.MaxHops is not set.
+	DefaultMaxRedirects = 10
+)
+
+var (
+	// ErrTooManyRedirects is returned when dialing is redirected more times
+	// than the RedirectPolicy allows.
+	ErrTooManyRedirects = errors.New("too many redirects")
+
+	// ErrRedirectLoop is returned when dialing is redirected to a URL that
+	// has already been tried.
+	ErrRedirectLoop = errors.New("redirect loop detected")
+
+	// ErrRedirectNotAllowed is returned when the RedirectPolicy refuses a
+	// redirect, such as to another host when SameHost is set.
+	ErrRedirectNotAllowed = errors.New("redirect not allowed")
+
+	errNoLocation = errors.New("redirect has no Location header")
+)
+
+// sensitiveHeaders are only sent to the host first dialed and its
+// subdomains, the same as net/http does when following redirects.
+var sensitiveHeaders = []string{"Authorization", "Www-Authenticate", "Cookie", "Cookie2"}
+
+// defaultRedirectCodes are the status codes followed when
+// RedirectPolicy.StatusCodes is not set.
+var defaultRedirectCodes = []int{
+	http.StatusMovedPermanently,
+	http.StatusFound,
+	http.StatusTemporaryRedirect,
+	http.StatusPermanentRedirect,
+}
+
+// RedirectPolicy decides which redirects are followed when dialing XMiDT.
+// Credentials, such as the token in the Authorization header, are only sent
+// to the host first dialed and its subdomains, whatever the policy.
+type RedirectPolicy struct {
+	// MaxHops is the most redirects followed for one dial.  Defaults to
+	// DefaultMaxRedirects.  A negative value follows no redirects.
+	MaxHops int `yaml:"maxHops"`
+
+	// StatusCodes are the status codes that are followed.  Defaults to 301,
+	// 302, 307 and 308.
+	StatusCodes []int `yaml:"statusCodes"`
+
+	// SameHost only allows redirects to the host of the URL first dialed,
+	// though the port may change.
+	SameHost bool `yaml:"sameHost"`
+
+	// CheckRedirect, if set, is called before following each redirect with
+	// the URL being redirected to and the URLs dialed so far, starting with
+	// the first.  Returning an error stops the redirect, and the error is
+	// returned from dialing.
+	CheckRedirect func(to *url.URL, statusCode int, via []*url.URL) error `yaml:"-"`
+}
+
+// follows reports whether the status code is a redirect the policy follows.
+func (p RedirectPolicy) follows(statusCode int) bool {
+	if p.MaxHops < 0 {
+		return false
+	}
+	codes := p.StatusCodes
+	if len(codes) == 0 {
+		codes = defaultRedirectCodes
+	}
+	return slices.Contains(codes, statusCode)
+}
+
+// redirects tracks the URLs dialed while following redirects.
+type redirects struct {
+	policy RedirectPolicy
+	via    []*url.URL
+}
+
+func newRedirects(policy RedirectPolicy, first *url.URL) *redirects {
+	return &redirects{policy: policy, via: []*url.URL{first}}
+}
+
+// next resolves the Location of a redirect against the URL last dialed and
+// checks it against the policy.  It returns the resolved Location, and the
+// websocket URL to dial next.
+func (r *redirects) next(location string, statusCode int) (*url.URL, string, error) {
+	if location == "" {
+		return nil, "", errNoLocation
+	}
+	maxHops := r.policy.MaxHops
+	if maxHops == 0 {
+		maxHops = DefaultMaxRedirects
+	}
+	if len(r.via)-1 >= maxHops {
+		return nil, "", fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, maxHops)
+	}
+
+	last := r.via[len(r.via)-1]
+	ref, err := url.Parse(location)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid redirect location [%v]: %w", location, err)
+	}
+	to := last.ResolveReference(ref)
+	wsURL, err := websocketURL(to.String())
+	if err != nil {
+		return nil, "", err
+	}
+	target, err := url.Parse(wsURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, u := range r.via {
+		if sameURL(u, target) {
+			return nil, "", fmt.Errorf("%w: %v", ErrRedirectLoop, r.describe(target))
+		}
+	}
+	if r.policy.SameHost && !strings.EqualFold(target.Hostname(), r.via[0].Hostname()) {
+		return nil, "", fmt.Errorf("%w: %v is not on %v", ErrRedirectNotAllowed, target.Hostname(), r.via[0].Hostname())
+	}
+	if r.policy.CheckRedirect != nil {
+		if err := r.policy.CheckRedirect(to, statusCode, slices.Clone(r.via)); err != nil {
+			return nil, "", err
+		}
+	}
+
+	r.via = append(r.via, target)
+	return to, wsURL, nil
+}
+
+// headers gives the headers to send to the URL dialed last.  Credentials,
+// such as the Authorization header, are left out unless it is on the host
+// first dialed or one of its subdomains.
+func (r *redirects) headers(headers http.Header) http.Header {
+	first, last := r.via[0].Hostname(), r.via[len(r.via)-1].Hostname()
+	if isDomainOrSubdomain(last, first) {
+		return headers
+	}
+	headers = headers.Clone()
+	for _, name := range sensitiveHeaders {
+		headers.Del(name)
+	}
+	return headers
+}
+
+// isDomainOrSubdomain reports whether sub is the same host as parent, or one
+// of its subdomains.
+func isDomainOrSubdomain(sub, parent string) bool {
+	sub, parent = strings.ToLower(sub), strings.ToLower(parent)
+	if sub == parent {
+		return true
+	}
+	// IPv6 addresses have no subdomains.
+	if strings.ContainsAny(sub, ":%") {
+		return false
+	}
+	return strings.HasSuffix(sub, "."+parent)
+}
+
+// redirected returns the URLs that redirected the client, leaving out the
+// URL dialed last.
+func (r *redirects) redirected() []string {
+	urls := make([]string, 0, len(r.via)-1)
+	for _, u := range r.via[:len(r.via)-1] {
+		urls = append(urls, u.String())
+	}
+	return urls
+}
+
+// describe describes the URLs dialed, ending with the one that repeats.
+func (r *redirects) describe(last *url.URL) string {
+	urls := make([]string, 0, len(r.via)+1)
+	for _, u := range r.via {
+		urls = append(urls, u.String())
+	}
+	return strings.Join(append(urls, last.String()), " -> ")
+}
+
+// sameURL reports whether the URLs refer to the same resource, ignoring the
+// case of the scheme and host.
+func sameURL(a, b *url.URL) bool {
+	return strings.EqualFold(a.Scheme, b.Scheme) &&
+		strings.EqualFold(a.Host, b.Host) &&
+		a.EscapedPath() == b.EscapedPath() &&
+		a.RawQuery == b.RawQuery
+}
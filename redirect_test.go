@@ -0,0 +1,225 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectPolicyFollows(t *testing.T) {
+	assert := assert.New(t)
+
+	var policy RedirectPolicy
+	for _, code := range []int{301, 302, 307, 308} {
+		assert.True(policy.follows(code), code)
+	}
+	assert.False(policy.follows(303))
+	assert.False(policy.follows(200))
+
+	policy.StatusCodes = []int{307}
+	assert.True(policy.follows(307))
+	assert.False(policy.follows(308))
+
+	policy.MaxHops = -1
+	assert.False(policy.follows(307))
+}
+
+func TestRedirectsNext(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	first, err := url.Parse("wss://petasos:6400/api/v2/device")
+	require.NoError(err)
+
+	// relative locations keep their own query.
+	r := newRedirects(RedirectPolicy{}, first)
+	location, wsURL, err := r.next("/api/v3/device?region=east", 307)
+	require.NoError(err)
+	assert.Equal("wss://petasos:6400/api/v3/device?region=east", location.String())
+	assert.Equal("wss://petasos:6400/api/v3/device?region=east", wsURL)
+
+	// absolute http locations are converted to websocket URLs.
+	location, wsURL, err = r.next("https://talaria:6200/api/v2/device", 302)
+	require.NoError(err)
+	assert.Equal("https://talaria:6200/api/v2/device", location.String())
+	assert.Equal("wss://talaria:6200/api/v2/device", wsURL)
+
+	_, _, err = r.next("HTTPS://PETASOS:6400/api/v2/device", 307)
+	assert.ErrorIs(err, ErrRedirectLoop)
+	assert.ErrorContains(err, "wss://petasos:6400/api/v2/device -> wss://petasos:6400/api/v3/device?region=east -> wss://talaria:6200/api/v2/device -> wss://PETASOS:6400/api/v2/device")
+
+	_, _, err = r.next("", 307)
+	assert.ErrorIs(err, errNoLocation)
+	_, _, err = r.next("ftp://talaria", 307)
+	assert.Error(err)
+
+	r = newRedirects(RedirectPolicy{MaxHops: 1}, first)
+	_, _, err = r.next("/one", 307)
+	require.NoError(err)
+	_, _, err = r.next("/two", 307)
+	assert.ErrorIs(err, ErrTooManyRedirects)
+
+	r = newRedirects(RedirectPolicy{SameHost: true}, first)
+	_, _, err = r.next("wss://petasos:6401/api/v2/device", 307)
+	require.NoError(err)
+	_, _, err = r.next("wss://talaria/api/v2/device", 307)
+	assert.ErrorIs(err, ErrRedirectNotAllowed)
+
+	errStop := errors.New("stop")
+	var via []*url.URL
+	r = newRedirects(RedirectPolicy{
+		CheckRedirect: func(to *url.URL, statusCode int, v []*url.URL) error {
+			via = v
+			if to.Host == "blocked" {
+				return errStop
+			}
+			return nil
+		},
+	}, first)
+	_, _, err = r.next("wss://talaria/api/v2/device", 307)
+	require.NoError(err)
+	assert.Equal([]*url.URL{first}, via)
+	_, _, err = r.next("wss://blocked/api/v2/device", 307)
+	assert.ErrorIs(err, errStop)
+	assert.Len(via, 2)
+}
+
+func TestClientRedirects(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusTemporaryRedirect)
+		case "/moved":
+			w.Header().Set("Location", "/api/v2/device?moved=true")
+			w.WriteHeader(http.StatusPermanentRedirect)
+		default:
+			upgrader.Upgrade(w, r, nil)
+		}
+	}))
+	defer server.Close()
+
+	recorder, listener := newRecordingListener()
+	config := clientConfig
+	config.DestinationURL = server.URL + "/moved"
+	config.Listeners = []ClientListener{listener}
+	config.HostnameFunc = func(connectionURL string) string { return "custom" }
+	testClient, err := NewClient(config)
+	require.NoError(err)
+	require.NoError(testClient.Close())
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	assert.Equal([]string{wsURL + "/moved"}, testClient.RedirectChain())
+	assert.Equal(wsURL+"/api/v2/device?moved=true", testClient.ConnectionURL())
+	assert.Equal(strings.TrimPrefix(server.URL, "http://"), testClient.RemoteAddr().String())
+	assert.Equal("custom", testClient.Hostname())
+
+	redirects := recorder.get("redirect")
+	require.Len(redirects, 1)
+	assert.Equal(http.StatusPermanentRedirect, redirects[0].StatusCode)
+	assert.Contains(redirects[0].Location, "/api/v2/device?moved=true")
+
+	config.DestinationURL = server.URL + "/loop"
+	config.Listeners = nil
+	config.HostnameFunc = nil
+	_, err = NewClient(config)
+	assert.ErrorIs(err, ErrRedirectLoop)
+
+	// redirects that aren't followed are returned as errors.
+	config.Redirect = RedirectPolicy{MaxHops: -1}
+	_, err = NewClient(config)
+	var statusErr StatusCoder
+	require.True(errors.As(err, &statusErr))
+	assert.Equal(http.StatusTemporaryRedirect, statusErr.StatusCode())
+}
+
+func TestRedirectsHeaders(t *testing.T) {
+	first, err := url.Parse("wss://talaria.example.com:6200/api/v2/device")
+	require.NoError(t, err)
+	headers := http.Header{"Authorization": {"Bearer sat"}, "Cookie": {"a=b"}, "X-Webpa-Device-Name": {"mac:ffffff112233"}}
+
+	tests := []struct {
+		location    string
+		credentials bool
+	}{
+		{"wss://talaria.example.com:6201/api/v2/device", true},
+		{"wss://TALARIA.example.com/api/v2/device", true},
+		{"wss://east.talaria.example.com/api/v2/device", true},
+		{"wss://example.com/api/v2/device", false},
+		{"wss://eviltalaria.example.com/api/v2/device", false},
+		{"wss://attacker.example.net/api/v2/device", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.location, func(t *testing.T) {
+			assert := assert.New(t)
+			r := newRedirects(RedirectPolicy{}, first)
+			_, _, err := r.next(tc.location, http.StatusTemporaryRedirect)
+			require.NoError(t, err)
+
+			sent := r.headers(headers)
+			assert.Equal("mac:ffffff112233", sent.Get("X-Webpa-Device-Name"))
+			if tc.credentials {
+				assert.Equal("Bearer sat", sent.Get("Authorization"))
+				assert.Equal("a=b", sent.Get("Cookie"))
+			} else {
+				assert.Empty(sent.Values("Authorization"))
+				assert.Empty(sent.Values("Cookie"))
+			}
+			// the headers given are left alone.
+			assert.Equal("Bearer sat", headers.Get("Authorization"))
+		})
+	}
+}
+
+func TestClientRedirectAuthorization(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	authorizations := make(chan string, 2)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations <- r.Header.Get("Authorization")
+		upgrader.Upgrade(w, r, nil)
+	}))
+	defer target.Close()
+	otherHost := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/same":
+			http.Redirect(w, r, target.URL+"/api/v2/device", http.StatusTemporaryRedirect)
+		default:
+			http.Redirect(w, r, otherHost+"/api/v2/device", http.StatusTemporaryRedirect)
+		}
+	}))
+	defer origin.Close()
+
+	config := clientConfig
+	config.TokenAcquirer = TokenAcquirerFunc(func(context.Context) (Token, error) {
+		return Token{Value: "sat"}, nil
+	})
+
+	// the token is only sent to the host first dialed.
+	config.DestinationURL = origin.URL + "/same"
+	testClient, err := NewClient(config)
+	require.NoError(err)
+	require.NoError(testClient.Close())
+	assert.Equal("Bearer sat", <-authorizations)
+
+	config.DestinationURL = origin.URL + "/other"
+	testClient, err = NewClient(config)
+	require.NoError(err)
+	require.NoError(testClient.Close())
+	assert.Empty(<-authorizations)
+}
@@ -4,9 +4,12 @@
 package kratos
 
 import (
+	"cmp"
 	"fmt"
 	"regexp"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/goph/emperror"
 	"github.com/xmidt-org/wrp-go/v3"
@@ -50,18 +53,71 @@ func (e errInvalidHandler) Error() string {
 	return "handler cannot be nil"
 }
 
+// HandlerOrder decides which handler wins when more than one handler with the
+// same priority matches a destination.
+type HandlerOrder string
+
+const (
+	// OrderByRegistration picks the handler that was registered first.  This
+	// is the default.
+	OrderByRegistration HandlerOrder = "registration"
+
+	// OrderByLongestPrefix picks the handler whose regular expression has the
+	// longest literal prefix, then the handler that was registered first.
+	OrderByLongestPrefix HandlerOrder = "longest-prefix"
+)
+
+// validate checks that the HandlerOrder is one that is known.
+func (o HandlerOrder) validate() error {
+	switch o {
+	case "", OrderByRegistration, OrderByLongestPrefix:
+		return nil
+	default:
+		return fmt.Errorf("unknown handler order [%v]", o)
+	}
+}
+
 // HandlerConfig is the values that a consumer can set that specify the handler
-// to use for the regular expression.
+// to use for the regular expression.  Handlers with a higher Priority are
+// matched first.  Middleware wraps only this handler, inside any middleware
+// used by the whole registry.  Timeout, when set, replaces the HandleMsgQueue
+// timeout for this handler.
+//
+// Match narrows the messages the handler is given beyond the destinations
+// matching Regexp, such as by message type or source; a message must match
+// all of them.  An empty Regexp matches every destination.  Name identifies
+// the handler in the registry, and defaults to Regexp, so handlers that share
+// a Regexp need different Names.
 type HandlerConfig struct {
-	Regexp  string
-	Handler DownstreamHandler
+	Name       string
+	Regexp     string
+	Match      []Matcher
+	Handler    DownstreamHandler
+	Priority   int
+	Middleware []Middleware
+	Timeout    time.Duration
+}
+
+// key is the name the handler is registered under.
+func (c HandlerConfig) key() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Regexp
 }
 
 // HandlerGroup is an internal data type for Client interface
 // that helps keep track of registered handler functions.
 type HandlerGroup struct {
-	keyRegex *regexp.Regexp
-	handler  DownstreamHandler
+	name       string
+	keyRegex   *regexp.Regexp
+	match      []Matcher
+	handler    DownstreamHandler
+	middleware []Middleware
+	wrapped    DownstreamHandler
+	priority   int
+	timeout    time.Duration
+	sequence   uint64
 }
 
 // DownstreamHandler should be implemented by the user so that they
@@ -75,23 +131,40 @@ type DownstreamHandler interface {
 // DownstreamHandlers.
 type HandlerRegistry interface {
 	Add(string, DownstreamHandler) error
+	AddFunc(string, HandlerFunc) error
+	AddHandler(HandlerConfig) error
+	Use(...Middleware)
 	Remove(string)
 	GetHandler(string) (DownstreamHandler, error)
+	HandlerFor(*wrp.Message) (DownstreamHandler, error)
+	Handlers() []HandlerConfig
 	Close()
 }
 
 // handlerRegistry is our implementation for HandlerRegistry that can be used
-// concurrently.
+// concurrently.  Handlers are kept sorted in the order they are matched.
 type handlerRegistry struct {
-	store map[string]HandlerGroup
-	lock  sync.RWMutex
+	store      map[string]HandlerGroup
+	order      []string
+	orderBy    HandlerOrder
+	sequence   uint64
+	middleware []Middleware
+	lock       sync.RWMutex
 }
 
 // NewHandlerRegistry creates a handlerRegistry based on the initial handlers
-// given.
+// given, ordering handlers of the same priority by registration.
 func NewHandlerRegistry(config []HandlerConfig) (*handlerRegistry, error) {
+	return NewOrderedHandlerRegistry(OrderByRegistration, config)
+}
+
+// NewOrderedHandlerRegistry creates a handlerRegistry based on the initial
+// handlers given, ordering handlers of the same priority using the
+// HandlerOrder.
+func NewOrderedHandlerRegistry(orderBy HandlerOrder, config []HandlerConfig) (*handlerRegistry, error) {
 	registry := handlerRegistry{
-		store: make(map[string]HandlerGroup),
+		store:   make(map[string]HandlerGroup),
+		orderBy: orderBy,
 	}
 	errs := errorList{}
 	for _, c := range config {
@@ -103,9 +176,10 @@ func NewHandlerRegistry(config []HandlerConfig) (*handlerRegistry, error) {
 		if err != nil {
 			errs = append(errs, emperror.Wrap(err, fmt.Sprintf("failed to compile regular expression [%v]", c.Regexp)))
 		} else {
-			registry.store[c.Regexp] = HandlerGroup{keyRegex: r, handler: c.Handler}
+			registry.store[c.key()] = registry.newGroup(c, r)
 		}
 	}
+	registry.sort()
 	if len(errs) == 0 {
 		return &registry, nil
 	}
@@ -116,47 +190,183 @@ func NewHandlerRegistry(config []HandlerConfig) (*handlerRegistry, error) {
 // If there is already a handler for the regular expression given, it is
 // overwritten with the new handler.
 func (h *handlerRegistry) Add(regexpName string, handler DownstreamHandler) error {
-	if handler == nil {
+	return h.AddHandler(HandlerConfig{Regexp: regexpName, Handler: handler})
+}
+
+// AddFunc provides a way to add a function as a handler to a pre-existing
+// handlerRegistry, the same way as Add.
+func (h *handlerRegistry) AddFunc(regexpName string, fn HandlerFunc) error {
+	if fn == nil {
+		return errInvalidHandler{}
+	}
+	return h.Add(regexpName, fn)
+}
+
+// AddHandler provides a way to add a new handler with a priority to a
+// pre-existing handlerRegistry.  If there is already a handler with the name
+// given, it is overwritten with the new handler but keeps its place in the
+// registration order.
+func (h *handlerRegistry) AddHandler(config HandlerConfig) error {
+	if config.Handler == nil {
 		return errInvalidHandler{}
 	}
 	h.lock.Lock()
 	defer h.lock.Unlock()
-	r, err := regexp.Compile(regexpName)
+	r, err := regexp.Compile(config.Regexp)
 	if err != nil {
-		return emperror.WrapWith(err, "failed to compile regular expression", "regexp", regexpName)
+		return emperror.WrapWith(err, "failed to compile regular expression", "regexp", config.Regexp)
+	}
+	group := h.newGroup(config, r)
+	if existing, ok := h.store[config.key()]; ok {
+		group.sequence = existing.sequence
 	}
-	h.store[regexpName] = HandlerGroup{keyRegex: r, handler: handler}
+	h.store[config.key()] = group
+	h.sort()
 	return nil
 }
 
+// newGroup creates the HandlerGroup for a handler being registered.
+func (h *handlerRegistry) newGroup(config HandlerConfig, r *regexp.Regexp) HandlerGroup {
+	h.sequence++
+	group := HandlerGroup{
+		name:       config.Name,
+		keyRegex:   r,
+		match:      slices.Clone(config.Match),
+		handler:    config.Handler,
+		middleware: slices.Clone(config.Middleware),
+		priority:   config.Priority,
+		timeout:    config.Timeout,
+		sequence:   h.sequence,
+	}
+	h.wrap(&group)
+	return group
+}
+
+// wrap applies the registry's middleware, then the group's own middleware,
+// to the group's handler.  The lock must be held when calling wrap.
+func (h *handlerRegistry) wrap(group *HandlerGroup) {
+	group.wrapped = Chain(append(slices.Clone(h.middleware), group.middleware...)...)(group.handler)
+	if group.timeout > 0 {
+		group.wrapped = &timeoutHandler{DownstreamHandler: group.wrapped, timeout: group.timeout}
+	}
+}
+
+// timeoutHandler is a handler with its own timeout, which the HandleMsgQueue
+// uses instead of its own.
+type timeoutHandler struct {
+	DownstreamHandler
+	timeout time.Duration
+}
+
+// Use adds middleware that wraps every handler in the handlerRegistry,
+// including handlers added later.  Middleware added first is the outermost.
+func (h *handlerRegistry) Use(middleware ...Middleware) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.middleware = append(h.middleware, middleware...)
+	for key, group := range h.store {
+		h.wrap(&group)
+		h.store[key] = group
+	}
+}
+
+// sort puts the regular expressions in the order they should be matched.
+// The lock must be held when calling sort.
+func (h *handlerRegistry) sort() {
+	h.order = h.order[:0]
+	for key := range h.store {
+		h.order = append(h.order, key)
+	}
+	slices.SortFunc(h.order, func(a, b string) int {
+		x, y := h.store[a], h.store[b]
+		if c := cmp.Compare(y.priority, x.priority); c != 0 {
+			return c
+		}
+		if h.orderBy == OrderByLongestPrefix {
+			xPrefix, _ := x.keyRegex.LiteralPrefix()
+			yPrefix, _ := y.keyRegex.LiteralPrefix()
+			if c := cmp.Compare(len(yPrefix), len(xPrefix)); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(x.sequence, y.sequence)
+	})
+}
+
 // Remove provides a way to remove an already existing handler in the
-// handlerRegistry.
-func (h *handlerRegistry) Remove(regexpName string) {
+// handlerRegistry, by its name.
+func (h *handlerRegistry) Remove(name string) {
 	h.lock.Lock()
-	delete(h.store, regexpName)
+	delete(h.store, name)
+	h.sort()
 	h.lock.Unlock()
 }
 
-// GetHandler gives the handler whose regular expression matches the
-// destination given.  If there is no handler with a matching regular
-// expression, an error is returned.
+// GetHandler gives the first handler, in match order, for a message with
+// only the destination given.  Handlers whose Match needs more than the
+// destination are skipped.  See HandlerFor.
 func (h *handlerRegistry) GetHandler(destination string) (DownstreamHandler, error) {
+	return h.HandlerFor(&wrp.Message{Destination: destination})
+}
+
+// HandlerFor gives the first handler, in match order, whose regular
+// expression matches the message's destination and whose matchers all match
+// the message, wrapped in its middleware.  If there is no matching handler,
+// an error is returned.
+func (h *handlerRegistry) HandlerFor(msg *wrp.Message) (DownstreamHandler, error) {
 	h.lock.RLock()
 	defer h.lock.RUnlock()
-	for _, handler := range h.store {
-		if handler.keyRegex.MatchString(destination) {
-			return handler.handler, nil
+	for _, key := range h.order {
+		handler := h.store[key]
+		if handler.matches(msg) {
+			return handler.wrapped, nil
 		}
 	}
 	return nil, errNoDownstreamHandler{}
 }
 
-// Close calls the Close function on all the handlers in the handlerRegistry.
+// matches checks whether the message should be routed to the group's
+// handler.
+func (g HandlerGroup) matches(msg *wrp.Message) bool {
+	if !g.keyRegex.MatchString(msg.Destination) {
+		return false
+	}
+	for _, m := range g.match {
+		if !m(msg) {
+			return false
+		}
+	}
+	return true
+}
+
+// Handlers lists the registered handlers in the order they are matched.
+func (h *handlerRegistry) Handlers() []HandlerConfig {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	handlers := make([]HandlerConfig, 0, len(h.order))
+	for _, key := range h.order {
+		handler := h.store[key]
+		handlers = append(handlers, HandlerConfig{
+			Name:       handler.name,
+			Regexp:     handler.keyRegex.String(),
+			Match:      slices.Clone(handler.match),
+			Handler:    handler.handler,
+			Priority:   handler.priority,
+			Middleware: slices.Clone(handler.middleware),
+			Timeout:    handler.timeout,
+		})
+	}
+	return handlers
+}
+
+// Close calls the Close function on all the handlers in the handlerRegistry,
+// through their middleware.
 func (h *handlerRegistry) Close() {
 	h.lock.Lock()
 	defer h.lock.Unlock()
 	for key, handler := range h.store {
-		handler.handler.Close()
+		handler.wrapped.Close()
 		delete(h.store, key)
 	}
+	h.order = h.order[:0]
 }
@@ -5,7 +5,6 @@ package kratos
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"sync"
 	"sync/atomic"
@@ -32,6 +31,8 @@ type registryQueue struct {
 	sendFunc         sendWRPFunc
 	downstreamSender downstreamSender
 	deviceID         string
+	policy           OverflowPolicy
+	metrics          *Metrics
 	workers          *semaphore.Weighted
 	wg               sync.WaitGroup
 	logger           *zap.Logger
@@ -41,22 +42,20 @@ type registryQueue struct {
 
 // NewRegistryHandler returns a registryHandler, which sends wrp messages to
 // the correct handler in an asynchronous fashion.
-func NewRegistryHandler(senderFunc sendWRPFunc, registry HandlerRegistry, downstreamSender downstreamSender, maxWorkers int, queueSize int, deviceID string, logger *zap.Logger) *registryQueue {
-	size := queueSize
+func NewRegistryHandler(senderFunc sendWRPFunc, registry HandlerRegistry, downstreamSender downstreamSender, config QueueConfig, deviceID string, metrics *Metrics, logger *zap.Logger) *registryQueue {
+	size := config.Size
 	if size < minQueueSize {
 		size = minQueueSize
 	}
-	numWorkers := maxWorkers
-	if numWorkers < minWorkers {
-		numWorkers = minWorkers
-	}
 	r := registryQueue{
 		incoming:         make(chan *wrp.Message, size),
 		registry:         registry,
 		sendFunc:         senderFunc,
 		downstreamSender: downstreamSender,
 		deviceID:         deviceID,
-		workers:          semaphore.NewWeighted(int64(numWorkers)),
+		policy:           config.Overflow,
+		metrics:          metrics,
+		workers:          config.newWorkers(),
 		logger:           logger,
 	}
 	r.wg.Add(1)
@@ -65,13 +64,21 @@ func NewRegistryHandler(senderFunc sendWRPFunc, registry HandlerRegistry, downst
 }
 
 // GetHandlerThenSend adds the message to the queue, so it can be handled when
-// there are appropriate resources.
+// there are appropriate resources.  When the queue is full, the queue's
+// OverflowPolicy is followed.
 func (r *registryQueue) GetHandlerThenSend(msg *wrp.Message) {
 	switch r.closed.Load() {
 	case true:
-		fmt.Println("d")
+		r.logger.Error("Failed to queue message. RegistryQueue is no longer accepting messages.")
 	default:
-		r.incoming <- msg
+		err := enqueue(context.Background(), r.incoming, msg, r.policy, func(*wrp.Message) {
+			r.logger.Warn("Dropped oldest message from RegistryQueue.")
+			r.metrics.droppedOldest(StageRegistry)
+		})
+		r.metrics.enqueueResult(StageRegistry, err)
+		if err != nil {
+			r.logger.Error("Failed to queue message.", zap.Error(err))
+		}
 	}
 }
 
@@ -94,6 +101,7 @@ func (r *registryQueue) startGettingHandlers() {
 	ctx := context.Background()
 	defer r.wg.Done()
 	for i := range r.incoming {
+		r.metrics.dequeued(StageRegistry)
 		r.workers.Acquire(ctx, 1)
 		r.wg.Add(1)
 		go r.getHandler(i)
@@ -105,10 +113,12 @@ func (r *registryQueue) startGettingHandlers() {
 func (r *registryQueue) getHandler(msg *wrp.Message) {
 	defer r.wg.Done()
 	defer r.workers.Release(1)
+	r.metrics.workerStarted(StageRegistry)
+	defer r.metrics.workerDone(StageRegistry)
 
 	r.logger.Debug("Getting handler...")
 
-	handler, err := r.registry.GetHandler(msg.Destination)
+	handler, err := r.registry.HandlerFor(msg)
 	if _, ok := err.(ErrNoDownstreamHandler); ok {
 		// If no valid handlers for the destination, create a new simple RequestResponse wrp with http Status Code of Service Unavailable
 		response := CreateErrorWRP(msg.TransactionUUID, msg.Source, r.deviceID, http.StatusServiceUnavailable, emperror.Wrap(err, "unable to get handler"))
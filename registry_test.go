@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func regexps(handlers []HandlerConfig) []string {
+	names := make([]string, 0, len(handlers))
+	for _, h := range handlers {
+		names = append(names, h.Regexp)
+	}
+	return names
+}
+
+func TestHandlerRegistryOrder(t *testing.T) {
+	tests := []struct {
+		description string
+		orderBy     HandlerOrder
+		handlers    []HandlerConfig
+		destination string
+		expected    []string
+	}{
+		{
+			description: "Registration",
+			orderBy:     OrderByRegistration,
+			handlers: []HandlerConfig{
+				{Regexp: ".*"},
+				{Regexp: "/foo"},
+				{Regexp: "/foo/bar"},
+			},
+			expected: []string{".*", "/foo", "/foo/bar"},
+		},
+		{
+			description: "Priority",
+			orderBy:     OrderByRegistration,
+			handlers: []HandlerConfig{
+				{Regexp: ".*", Priority: -1},
+				{Regexp: "/foo"},
+				{Regexp: "/foo/bar", Priority: 10},
+			},
+			expected: []string{"/foo/bar", "/foo", ".*"},
+		},
+		{
+			description: "Longest Prefix",
+			orderBy:     OrderByLongestPrefix,
+			handlers: []HandlerConfig{
+				{Regexp: ".*"},
+				{Regexp: "/foo"},
+				{Regexp: "/foo/bar"},
+				{Regexp: "/bar"},
+			},
+			expected: []string{"/foo/bar", "/foo", "/bar", ".*"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			require := require.New(t)
+			for i := range tc.handlers {
+				tc.handlers[i].Handler = &myReadHandler{}
+			}
+			registry, err := NewOrderedHandlerRegistry(tc.orderBy, tc.handlers)
+			require.NoError(err)
+			require.Equal(tc.expected, regexps(registry.Handlers()))
+
+			handler, err := registry.GetHandler("/foo/bar")
+			require.NoError(err)
+			require.Same(registry.store[tc.expected[0]].handler, handler)
+		})
+	}
+}
+
+func TestHandlerRegistryAddRemove(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	registry, err := NewHandlerRegistry([]HandlerConfig{
+		{Regexp: "/foo", Handler: &myReadHandler{}},
+		{Regexp: "/bar", Handler: &myReadHandler{}},
+	})
+	require.NoError(err)
+
+	catchAll := &myReadHandler{}
+	require.NoError(registry.AddHandler(HandlerConfig{Regexp: ".*", Handler: catchAll, Priority: 1}))
+	assert.Equal([]string{".*", "/foo", "/bar"}, regexps(registry.Handlers()))
+
+	// replacing a handler keeps its place in the registration order.
+	require.NoError(registry.Add("/foo", &myReadHandler{}))
+	registry.Remove(".*")
+	assert.Equal([]string{"/foo", "/bar"}, regexps(registry.Handlers()))
+
+	_, err = registry.GetHandler("/baz")
+	assert.Implements((*ErrNoDownstreamHandler)(nil), err)
+	assert.Implements((*ErrInvalidHandler)(nil), registry.AddHandler(HandlerConfig{Regexp: "/baz"}))
+	assert.Error(registry.Add("(", &myReadHandler{}))
+
+	registry.Close()
+	assert.Empty(registry.Handlers())
+}
+
+func TestHandlerRegistryTimeout(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	registry, err := NewHandlerRegistry(nil)
+	require.NoError(err)
+	handler := &echoHandler{}
+	require.NoError(registry.AddHandler(HandlerConfig{Regexp: "/slow", Handler: handler, Timeout: time.Second}))
+
+	found, err := registry.GetHandler("/slow")
+	require.NoError(err)
+	timed, ok := found.(*timeoutHandler)
+	require.True(ok)
+	assert.Equal(time.Second, timed.timeout)
+	assert.Same(handler, timed.DownstreamHandler)
+	assert.Equal(time.Second, registry.Handlers()[0].Timeout)
+}
@@ -7,24 +7,56 @@ import (
 	"context"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
-	"golang.org/x/sync/semaphore"
+)
+
+const (
+	// controlQueueSize is the number of control messages, such as pongs, that
+	// can wait to be written at once.
+	controlQueueSize = 8
 )
 
 // outboundSender provides a way to send wrps.
 type outboundSender interface {
-	Send([]byte)
+	Send([]byte) error
+	SendControl(messageType int, data []byte)
 	Close()
 }
 
+// WriteConfig configures how messages are written to the websocket.
+type WriteConfig struct {
+	// Timeout is the time allowed to write the messages sharing a write
+	// deadline.  Defaults to ten seconds.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// MessagesPerDeadline is the maximum number of queued messages written
+	// under a single write deadline, saving a deadline update for every
+	// message when the queue is backed up.  Every message is still written
+	// and flushed as its own websocket frame.  Defaults to 1, meaning every
+	// message gets its own deadline.
+	MessagesPerDeadline int `yaml:"messagesPerDeadline"`
+}
+
+// outboundMessage is a message waiting to be written to the websocket.
+type outboundMessage struct {
+	messageType int
+	data        []byte
+}
+
 // senderQueue implements the outboundSender, allowing for asynchronous sending
-// through a websocket connection.
+// through a websocket connection.  The websocket connection only supports one
+// concurrent writer, so all messages, including control messages, are written
+// by a single goroutine.
 type senderQueue struct {
 	incoming   chan []byte
+	control    chan outboundMessage
 	connection websocketConnection
-	workers    *semaphore.Weighted
+	policy     OverflowPolicy
+	metrics    *Metrics
+	config     WriteConfig
 	wg         sync.WaitGroup
 	logger     *zap.Logger
 	once       sync.Once
@@ -33,19 +65,24 @@ type senderQueue struct {
 
 // NewSender creates a new senderQueue with the given websocketConnection and
 // other configuration.
-func NewSender(connection websocketConnection, maxWorkers int, queueSize int, logger *zap.Logger) *senderQueue {
-	size := queueSize
+func NewSender(connection websocketConnection, queueConfig QueueConfig, config WriteConfig, metrics *Metrics, logger *zap.Logger) *senderQueue {
+	size := queueConfig.Size
 	if size < minQueueSize {
 		size = minQueueSize
 	}
-	numWorkers := maxWorkers
-	if numWorkers < minWorkers {
-		numWorkers = minWorkers
+	if config.Timeout <= 0 {
+		config.Timeout = writeWait
+	}
+	if config.MessagesPerDeadline < 1 {
+		config.MessagesPerDeadline = 1
 	}
 	s := senderQueue{
 		incoming:   make(chan []byte, size),
+		control:    make(chan outboundMessage, controlQueueSize),
 		connection: connection,
-		workers:    semaphore.NewWeighted(int64(numWorkers)),
+		policy:     queueConfig.Overflow,
+		metrics:    metrics,
+		config:     config,
 		logger:     logger,
 	}
 	s.wg.Add(1)
@@ -53,13 +90,32 @@ func NewSender(connection websocketConnection, maxWorkers int, queueSize int, lo
 	return &s
 }
 
-// Send adds the message given to the queue of messages to be sent.
-func (s *senderQueue) Send(msg []byte) {
-	switch s.closed.Load() {
-	case true:
+// Send adds the message given to the queue of messages to be sent.  When the
+// queue is full, the queue's OverflowPolicy is followed.
+func (s *senderQueue) Send(msg []byte) error {
+	if s.closed.Load() == true {
 		s.logger.Error("Failed to queue message. SenderWorker is no longer accepting messages.")
+		return ErrClientClosed
+	}
+	err := enqueue(context.Background(), s.incoming, msg, s.policy, func([]byte) {
+		s.logger.Warn("Dropped oldest message from SenderWorker.")
+		s.metrics.droppedOldest(StageOutbound)
+	})
+	s.metrics.enqueueResult(StageOutbound, err)
+	return err
+}
+
+// SendControl queues a control message, such as a pong, to be written ahead
+// of any queued data messages.  It never blocks; if too many control messages
+// are already waiting, the message is dropped.
+func (s *senderQueue) SendControl(messageType int, data []byte) {
+	if s.closed.Load() == true {
+		return
+	}
+	select {
+	case s.control <- outboundMessage{messageType: messageType, data: data}:
 	default:
-		s.incoming <- msg
+		s.logger.Warn("Dropped control message. Too many control messages queued.")
 	}
 }
 
@@ -73,30 +129,77 @@ func (s *senderQueue) Close() {
 	})
 }
 
-// startSending is called when the senderQueue is created, allowing the queue
-// to read the incoming messages and send them.
+// startSending is called when the senderQueue is created.  It is the only
+// goroutine that writes to the connection, giving control messages priority
+// over queued data messages.
 func (s *senderQueue) startSending() {
-	ctx := context.Background()
 	defer s.wg.Done()
-	for i := range s.incoming {
-		s.workers.Acquire(ctx, 1)
-		s.wg.Add(1)
-		go s.send(i)
+	for {
+		select {
+		case c := <-s.control:
+			s.send(c)
+			continue
+		default:
+		}
+
+		select {
+		case c := <-s.control:
+			s.send(c)
+		case msg, ok := <-s.incoming:
+			if !ok {
+				return
+			}
+			s.metrics.dequeued(StageOutbound)
+			s.sendWithDeadline(msg)
+		}
 	}
 }
 
-// send takes the incoming message and actually sends it.
-func (s *senderQueue) send(incoming []byte) {
-	defer s.wg.Done()
-	defer s.workers.Release(1)
+// sendWithDeadline writes the message along with up to MessagesPerDeadline-1
+// more messages that are already waiting in the queue, all under a single
+// write deadline.
+func (s *senderQueue) sendWithDeadline(first []byte) {
+	s.setDeadline()
+	s.write(outboundMessage{messageType: websocket.BinaryMessage, data: first})
+	for i := 1; i < s.config.MessagesPerDeadline; i++ {
+		select {
+		case msg, ok := <-s.incoming:
+			if !ok {
+				return
+			}
+			s.metrics.dequeued(StageOutbound)
+			s.write(outboundMessage{messageType: websocket.BinaryMessage, data: msg})
+		default:
+			return
+		}
+	}
+}
+
+// send writes a single message under its own write deadline.
+func (s *senderQueue) send(msg outboundMessage) {
+	s.setDeadline()
+	s.write(msg)
+}
+
+// setDeadline sets the write deadline for the next message or messages.
+func (s *senderQueue) setDeadline() {
+	err := s.connection.SetWriteDeadline(time.Now().Add(s.config.Timeout))
+	if err != nil {
+		s.logger.Warn("Failed to set write deadline", zap.Error(err))
+	}
+}
 
+// write takes the outgoing message and actually sends it.
+func (s *senderQueue) write(msg outboundMessage) {
+	s.metrics.workerStarted(StageOutbound)
+	defer s.metrics.workerDone(StageOutbound)
 	s.logger.Debug("Sending message...")
 
-	err := s.connection.WriteMessage(websocket.BinaryMessage, incoming)
+	err := s.connection.WriteMessage(msg.messageType, msg.data)
 	if err != nil {
 		s.logger.Error("Failed to send message",
 			zap.Error(err),
-			zap.String("msg", string(incoming)))
+			zap.String("msg", string(msg.data)))
 		return
 	}
 
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/sallust"
+)
+
+// serialConnection records writes and notices if they ever overlap.
+type serialConnection struct {
+	writing    atomic.Bool
+	overlapped atomic.Bool
+	lock       sync.Mutex
+	types      []int
+	deadlines  int
+}
+
+func (s *serialConnection) WriteMessage(messageType int, _ []byte) error {
+	if !s.writing.CompareAndSwap(false, true) {
+		s.overlapped.Store(true)
+	}
+	time.Sleep(time.Millisecond)
+	s.lock.Lock()
+	s.types = append(s.types, messageType)
+	s.lock.Unlock()
+	s.writing.Store(false)
+	return nil
+}
+
+func (s *serialConnection) SetWriteDeadline(time.Time) error {
+	s.lock.Lock()
+	s.deadlines++
+	s.lock.Unlock()
+	return nil
+}
+
+func (s *serialConnection) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (s *serialConnection) ReadMessage() (int, []byte, error) {
+	return 0, nil, nil
+}
+
+func (s *serialConnection) Close() error {
+	return nil
+}
+
+func TestSenderSingleWriter(t *testing.T) {
+	assert := assert.New(t)
+	conn := &serialConnection{}
+	sender := NewSender(conn, QueueConfig{Size: 100}, WriteConfig{MessagesPerDeadline: 10}, nil, sallust.Default())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sender.Send([]byte("message"))
+		}()
+	}
+	sender.SendControl(websocket.PongMessage, []byte("ping"))
+	wg.Wait()
+	sender.Close()
+
+	assert.False(conn.overlapped.Load())
+	assert.Len(conn.types, 51)
+	assert.Contains(conn.types, websocket.PongMessage)
+}
+
+func TestSenderControlAfterClose(t *testing.T) {
+	conn := &serialConnection{}
+	sender := NewSender(conn, QueueConfig{Size: 1}, WriteConfig{}, nil, sallust.Default())
+	sender.Close()
+	sender.SendControl(websocket.PongMessage, []byte("ping"))
+	assert.Empty(t, conn.types)
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	errNoCertificatesFound = errors.New("no certificates found")
+	errIncompleteKeyPair   = errors.New("both a certificate file and key file must be provided")
+)
+
+// TLSConfig configures the TLS used when connecting to XMiDT over wss.  It
+// supports both server verification and mutual TLS, where the emulated device
+// presents its own certificate.
+type TLSConfig struct {
+	// Certificates are the client certificates to present to the server.
+	Certificates []tls.Certificate `yaml:"-"`
+
+	// CertificateFile and KeyFile are the PEM encoded client certificate and
+	// private key to present to the server.  They are loaded in addition to
+	// Certificates.
+	CertificateFile string `yaml:"certificateFile"`
+	KeyFile         string `yaml:"keyFile"`
+
+	// RootCAs is the pool of certificate authorities used to verify the
+	// server.  If both RootCAs and RootCAFiles are empty, the system pool is
+	// used.
+	RootCAs *x509.CertPool `yaml:"-"`
+
+	// RootCAFiles are PEM encoded certificate authorities added to RootCAs.
+	RootCAFiles []string `yaml:"rootCAFiles"`
+
+	// ServerName is used to verify the server's certificate.  It defaults to
+	// the host being connected to.
+	ServerName string `yaml:"serverName"`
+
+	// MinVersion is the minimum TLS version accepted.  Defaults to TLS 1.2.
+	MinVersion uint16 `yaml:"minVersion"`
+
+	// InsecureSkipVerify disables verification of the server's certificate.
+	// This should only be used for testing.
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify"`
+}
+
+// NewTLSConfig builds the *tls.Config described by the TLSConfig.
+func (t *TLSConfig) NewTLSConfig() (*tls.Config, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		ServerName:         t.ServerName,
+		MinVersion:         t.MinVersion,
+		InsecureSkipVerify: t.InsecureSkipVerify, //nolint:gosec
+	}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+
+	config.Certificates = append(config.Certificates, t.Certificates...)
+	if t.CertificateFile != "" || t.KeyFile != "" {
+		if t.CertificateFile == "" || t.KeyFile == "" {
+			return nil, errIncompleteKeyPair
+		}
+		cert, err := tls.LoadX509KeyPair(t.CertificateFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = append(config.Certificates, cert)
+	}
+
+	if t.RootCAs != nil || len(t.RootCAFiles) > 0 {
+		config.RootCAs = x509.NewCertPool()
+		if t.RootCAs != nil {
+			config.RootCAs = t.RootCAs.Clone()
+		}
+	}
+	for _, file := range t.RootCAFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read root CA file [%v]: %w", file, err)
+		}
+		if !config.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("failed to add root CA file [%v]: %w", file, errNoCertificatesFound)
+		}
+	}
+
+	return config, nil
+}
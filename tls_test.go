@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTLSTestServer() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader.Upgrade(w, r, nil)
+	}))
+}
+
+func TestNewTLS(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server := newTLSTestServer()
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	config := clientConfig
+	config.DestinationURL = server.URL
+	config.TLS = &TLSConfig{RootCAs: pool}
+	testClient, err := NewClient(config)
+	require.NoError(err)
+
+	assert.Equal("127.0.0.1", testClient.Hostname())
+	assert.NoError(testClient.Close())
+}
+
+func TestNewTLSRootCAFile(t *testing.T) {
+	require := require.New(t)
+
+	server := newTLSTestServer()
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	}), 0600))
+
+	config := clientConfig
+	config.DestinationURL = server.URL
+	config.TLS = &TLSConfig{RootCAFiles: []string{caFile}}
+	testClient, err := NewClient(config)
+	require.NoError(err)
+	require.NoError(testClient.Close())
+}
+
+func TestNewTLSUnknownAuthority(t *testing.T) {
+	server := newTLSTestServer()
+	defer server.Close()
+
+	config := clientConfig
+	config.DestinationURL = server.URL
+	config.TLS = &TLSConfig{RootCAs: x509.NewCertPool()}
+	_, err := NewClient(config)
+	assert.Error(t, err)
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	emptyFile := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(emptyFile, []byte{}, 0600))
+
+	tests := []struct {
+		description string
+		config      *TLSConfig
+		expectedErr error
+	}{
+		{
+			description: "Nil",
+		},
+		{
+			description: "Defaults",
+			config:      &TLSConfig{},
+		},
+		{
+			description: "Missing Key File",
+			config:      &TLSConfig{CertificateFile: "cert.pem"},
+			expectedErr: errIncompleteKeyPair,
+		},
+		{
+			description: "Empty Root CA File",
+			config:      &TLSConfig{RootCAFiles: []string{emptyFile}},
+			expectedErr: errNoCertificatesFound,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert := assert.New(t)
+			config, err := tc.config.NewTLSConfig()
+			if tc.expectedErr != nil {
+				assert.ErrorIs(err, tc.expectedErr)
+				return
+			}
+			assert.NoError(err)
+			if tc.config == nil {
+				assert.Nil(config)
+				return
+			}
+			assert.Equal(uint16(tls.VersionTLS12), config.MinVersion)
+		})
+	}
+}
+
+func TestWebsocketURL(t *testing.T) {
+	tests := []struct {
+		in          string
+		expected    string
+		expectedErr bool
+	}{
+		{in: "http://localhost:6200/api/v2/device", expected: "ws://localhost:6200/api/v2/device"},
+		{in: "https://localhost:6200/api/v2/device", expected: "wss://localhost:6200/api/v2/device"},
+		{in: "wss://localhost/api/v2/device", expected: "wss://localhost/api/v2/device"},
+		{in: "http://httpbin/http", expected: "ws://httpbin/http"},
+		{in: "broken.url", expectedErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.in, func(t *testing.T) {
+			assert := assert.New(t)
+			u, err := websocketURL(tc.in)
+			if tc.expectedErr {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expected, u)
+		})
+	}
+}
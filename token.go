@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// BearerTokenType is the default authorization scheme for tokens.
+	BearerTokenType = "Bearer"
+)
+
+var (
+	errEmptyToken = errors.New("token acquirer returned an empty token")
+)
+
+// Token is the credential sent in the Authorization header when the client
+// connects to XMiDT, such as a SAT.
+type Token struct {
+	// Type is the authorization scheme.  Defaults to Bearer.
+	Type string
+
+	// Value is the token itself.
+	Value string
+
+	// Expiration is when the token stops being valid.  The zero value means
+	// the token never expires.
+	Expiration time.Time
+}
+
+// authorization returns the value to use for the Authorization header.
+func (t Token) authorization() string {
+	tokenType := t.Type
+	if tokenType == "" {
+		tokenType = BearerTokenType
+	}
+	return tokenType + " " + t.Value
+}
+
+// TokenAcquirer provides the token for the client to use.  It is consulted
+// every time the client dials or redials XMiDT.
+type TokenAcquirer interface {
+	AcquireToken(ctx context.Context) (Token, error)
+}
+
+// TokenAcquirerFunc is a function that implements TokenAcquirer.
+type TokenAcquirerFunc func(ctx context.Context) (Token, error)
+
+// AcquireToken calls the function.
+func (f TokenAcquirerFunc) AcquireToken(ctx context.Context) (Token, error) {
+	return f(ctx)
+}
+
+// cachingTokenAcquirer holds on to a token until it is close to expiring.
+type cachingTokenAcquirer struct {
+	acquirer      TokenAcquirer
+	refreshBefore time.Duration
+	now           func() time.Time
+	lock          sync.Mutex
+	token         Token
+	cached        bool
+}
+
+// NewCachingTokenAcquirer wraps a TokenAcquirer so that a token is reused
+// until it expires.  If refreshBefore is greater than zero, a new token is
+// acquired once the cached token is within refreshBefore of expiring, so that
+// a connection is never attempted with a token about to go stale.  A token
+// that XMiDT rejects with a 401 or 403 is not used again.
+func NewCachingTokenAcquirer(acquirer TokenAcquirer, refreshBefore time.Duration) TokenAcquirer {
+	return &cachingTokenAcquirer{
+		acquirer:      acquirer,
+		refreshBefore: refreshBefore,
+		now:           time.Now,
+	}
+}
+
+// AcquireToken returns the cached token if it is still fresh, otherwise it
+// acquires and caches a new one.
+func (c *cachingTokenAcquirer) AcquireToken(ctx context.Context) (Token, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.cached && c.fresh() {
+		return c.token, nil
+	}
+
+	token, err := c.acquirer.AcquireToken(ctx)
+	if err != nil {
+		return Token{}, err
+	}
+	c.token = token
+	c.cached = true
+	return token, nil
+}
+
+// invalidate drops the cached token, so the next one is acquired anew.
+func (c *cachingTokenAcquirer) invalidate() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.token = Token{}
+	c.cached = false
+}
+
+// fresh determines if the cached token can still be used.
+func (c *cachingTokenAcquirer) fresh() bool {
+	if c.token.Expiration.IsZero() {
+		return true
+	}
+	return c.now().Add(c.refreshBefore).Before(c.token.Expiration)
+}
+
+// tokenInvalidator is a TokenAcquirer that holds on to tokens, and can be
+// told to stop using the one it has.
+type tokenInvalidator interface {
+	invalidate()
+}
+
+// invalidateToken drops the acquirer's cached token if the error shows that
+// XMiDT rejected it.
+func invalidateToken(acquirer TokenAcquirer, err error) {
+	var coder StatusCoder
+	if !errors.As(err, &coder) {
+		return
+	}
+	if code := coder.StatusCode(); code != http.StatusUnauthorized && code != http.StatusForbidden {
+		return
+	}
+	if i, ok := acquirer.(tokenInvalidator); ok {
+		i.invalidate()
+	}
+}
+
+// authorizationHeader gets the value for the Authorization header from the
+// TokenAcquirer.
+func authorizationHeader(ctx context.Context, acquirer TokenAcquirer) (string, error) {
+	token, err := acquirer.AcquireToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	if token.Value == "" {
+		return "", errEmptyToken
+	}
+	return token.authorization(), nil
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachingTokenAcquirer(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	now := time.Now()
+	calls := 0
+	acquirer := NewCachingTokenAcquirer(TokenAcquirerFunc(func(context.Context) (Token, error) {
+		calls++
+		return Token{Value: "sat", Expiration: now.Add(time.Hour)}, nil
+	}), 5*time.Minute).(*cachingTokenAcquirer)
+	acquirer.now = func() time.Time { return now }
+
+	token, err := acquirer.AcquireToken(context.Background())
+	require.NoError(err)
+	assert.Equal("sat", token.Value)
+	_, err = acquirer.AcquireToken(context.Background())
+	require.NoError(err)
+	assert.Equal(1, calls)
+
+	// inside the refresh window, so a new token should be acquired.
+	now = now.Add(56 * time.Minute)
+	_, err = acquirer.AcquireToken(context.Background())
+	require.NoError(err)
+	assert.Equal(2, calls)
+}
+
+func TestCachingTokenAcquirerError(t *testing.T) {
+	acquirer := NewCachingTokenAcquirer(TokenAcquirerFunc(func(context.Context) (Token, error) {
+		return Token{}, ErrFoo
+	}), 0)
+	_, err := acquirer.AcquireToken(context.Background())
+	assert.ErrorIs(t, err, ErrFoo)
+}
+
+func TestNewWithToken(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		upgrader.Upgrade(w, r, nil)
+	}))
+	defer server.Close()
+
+	config := clientConfig
+	config.DestinationURL = server.URL
+	config.TokenAcquirer = TokenAcquirerFunc(func(context.Context) (Token, error) {
+		return Token{Value: "sat"}, nil
+	})
+	testClient, err := NewClient(config)
+	require.NoError(err)
+	assert.Equal("Bearer sat", authorization)
+	assert.NoError(testClient.Close())
+
+	config.TokenAcquirer = TokenAcquirerFunc(func(context.Context) (Token, error) {
+		return Token{}, nil
+	})
+	_, err = NewClient(config)
+	assert.ErrorIs(err, errEmptyToken)
+}
+
+func TestCachingTokenAcquirerRejected(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// reject the first token, and accept the rest.
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		if len(authorizations) == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		upgrader.Upgrade(w, r, nil)
+	}))
+	defer server.Close()
+
+	calls := 0
+	config := clientConfig
+	config.DestinationURL = server.URL
+	config.TokenAcquirer = NewCachingTokenAcquirer(TokenAcquirerFunc(func(context.Context) (Token, error) {
+		calls++
+		return Token{Value: fmt.Sprintf("sat-%d", calls)}, nil
+	}), 0)
+	c, err := newClient(config)
+	require.NoError(err)
+	defer c.Close()
+
+	_, _, err = c.dial(context.Background(), 0)
+	require.Error(err)
+	conn, _, err := c.dial(context.Background(), 1)
+	require.NoError(err)
+	conn.Close()
+	assert.Equal([]string{"Bearer sat-1", "Bearer sat-2"}, authorizations)
+}
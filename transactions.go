@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+var (
+	errMissingTransactionUUID = errors.New("message must have a TransactionUUID to wait for a response")
+	errDuplicateTransaction   = errors.New("a request with this TransactionUUID is already waiting for a response")
+)
+
+// transactions tracks the requests that are waiting on a response, keyed by
+// TransactionUUID.
+type transactions struct {
+	lock    sync.Mutex
+	pending map[string]chan *wrp.Message
+}
+
+// newTransactions creates an empty set of transactions.
+func newTransactions() *transactions {
+	return &transactions{
+		pending: make(map[string]chan *wrp.Message),
+	}
+}
+
+// register starts waiting for a response to the given transaction.
+func (t *transactions) register(transactionUUID string) (<-chan *wrp.Message, error) {
+	if transactionUUID == "" {
+		return nil, errMissingTransactionUUID
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if _, ok := t.pending[transactionUUID]; ok {
+		return nil, errDuplicateTransaction
+	}
+	response := make(chan *wrp.Message, 1)
+	t.pending[transactionUUID] = response
+	return response, nil
+}
+
+// cancel stops waiting for a response to the given transaction.
+func (t *transactions) cancel(transactionUUID string) {
+	t.lock.Lock()
+	delete(t.pending, transactionUUID)
+	t.lock.Unlock()
+}
+
+// complete hands the message to the request waiting on it.  False is
+// returned if no request is waiting on the message.
+func (t *transactions) complete(msg *wrp.Message) bool {
+	if msg.Type != wrp.SimpleRequestResponseMessageType || msg.TransactionUUID == "" {
+		return false
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	response, ok := t.pending[msg.TransactionUUID]
+	if !ok {
+		return false
+	}
+	delete(t.pending, msg.TransactionUUID)
+	response <- msg
+	return true
+}
+
+// responseInterceptor is a registryHandler that gives responses to the
+// requests waiting on them before any other message reaches the
+// HandlerRegistry.
+type responseInterceptor struct {
+	transactions *transactions
+	next         registryHandler
+}
+
+// GetHandlerThenSend completes the matching transaction, or passes the
+// message on to the next registryHandler.
+func (r *responseInterceptor) GetHandlerThenSend(msg *wrp.Message) {
+	if r.transactions.complete(msg) {
+		return
+	}
+	r.next.GetHandlerThenSend(msg)
+}
+
+// Close closes the next registryHandler.
+func (r *responseInterceptor) Close() {
+	r.next.Close()
+}
+
+// Request sends the message and waits for the SimpleRequestResponse message
+// with the same TransactionUUID.  The response is not passed on to the
+// HandlerRegistry.  Request gives up when the context is done or the client is
+// closed.
+func (c *client) Request(ctx context.Context, message *wrp.Message) (*wrp.Message, error) {
+	response, err := c.transactions.register(message.TransactionUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer c.transactions.cancel(message.TransactionUUID)
+
+	if err = c.SendContext(ctx, message); err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-response:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.done:
+		return nil, ErrClientClosed
+	}
+}
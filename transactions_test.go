@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package kratos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+// recordingRegistryHandler keeps every message it is given.
+type recordingRegistryHandler struct {
+	msgs []*wrp.Message
+}
+
+func (r *recordingRegistryHandler) GetHandlerThenSend(msg *wrp.Message) {
+	r.msgs = append(r.msgs, msg)
+}
+
+func (r *recordingRegistryHandler) Close() {}
+
+func TestResponseInterceptor(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	next := &recordingRegistryHandler{}
+	txs := newTransactions()
+	interceptor := &responseInterceptor{transactions: txs, next: next}
+
+	response, err := txs.register("abc")
+	require.NoError(err)
+	_, err = txs.register("abc")
+	assert.ErrorIs(err, errDuplicateTransaction)
+	_, err = txs.register("")
+	assert.ErrorIs(err, errMissingTransactionUUID)
+
+	event := &wrp.Message{Type: wrp.SimpleEventMessageType, TransactionUUID: "abc"}
+	other := &wrp.Message{Type: wrp.SimpleRequestResponseMessageType, TransactionUUID: "def"}
+	match := &wrp.Message{Type: wrp.SimpleRequestResponseMessageType, TransactionUUID: "abc"}
+	interceptor.GetHandlerThenSend(event)
+	interceptor.GetHandlerThenSend(other)
+	interceptor.GetHandlerThenSend(match)
+
+	assert.Equal([]*wrp.Message{event, other}, next.msgs)
+	assert.Equal(match, <-response)
+
+	// the transaction is done, so the next message is not intercepted.
+	interceptor.GetHandlerThenSend(match)
+	assert.Len(next.msgs, 3)
+}
+
+func TestRequest(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// echo every message back, which makes a request its own response.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(messageType, data)
+		}
+	}))
+	defer server.Close()
+
+	config := clientConfig
+	config.DestinationURL = server.URL
+	testClient, err := NewClient(config)
+	require.NoError(err)
+	defer testClient.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	response, err := testClient.Request(ctx, &wrp.Message{
+		Type:            wrp.SimpleRequestResponseMessageType,
+		Source:          "mac:ffffff112233/emu",
+		Destination:     "dns:talaria/bar",
+		TransactionUUID: "emu:request",
+		Payload:         []byte("hello"),
+	})
+	require.NoError(err)
+	assert.Equal("emu:request", response.TransactionUUID)
+	assert.Equal([]byte("hello"), response.Payload)
+}
+
+func TestRequestTimeout(t *testing.T) {
+	require := require.New(t)
+
+	testClient, err := NewClient(clientConfig)
+	require.NoError(err)
+	defer testClient.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = testClient.Request(ctx, &wrp.Message{
+		Type:            wrp.SimpleRequestResponseMessageType,
+		TransactionUUID: "emu:timeout",
+	})
+	require.ErrorIs(err, context.DeadlineExceeded)
+
+	_, err = testClient.Request(ctx, &wrp.Message{Type: wrp.SimpleRequestResponseMessageType})
+	require.ErrorIs(err, errMissingTransactionUUID)
+}